@@ -4,11 +4,12 @@ mkdir -p build/dist
 
 GOOS=${GOOS:-linux}
 GOARCH=${GOARCH:-amd64}
+VERSION=${VERSION:-$(git describe --tags --always --dirty 2>/dev/null || echo dev)}
 
 for cmd in ./cmd/*; do
     go get -C "$cmd"
 
-    ldflags="-w -s $([ -n "${CONTAINERIZED:-}" ] && echo "-linkmode external -extldflags -static")"
+    ldflags="-w -s -X github.com/mplus-oss/bobbit.go/config.Version=$VERSION $([ -n "${CONTAINERIZED:-}" ] && echo "-linkmode external -extldflags -static")"
     output="$(basename "$cmd")-$GOOS-$GOARCH"
 
     go build -C "$cmd" -ldflags="$ldflags" -o "../../build/dist/$output"
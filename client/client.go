@@ -2,19 +2,36 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/mplus-oss/bobbit.go/config"
 	"github.com/mplus-oss/bobbit.go/payload"
 )
 
-// DaemonConnectionStruct holds the network connection and configuration for a Bobbit daemon client.
+// DaemonConnectionStruct is the client of a Bobbit daemon. Every request uses its own
+// connection, so a single client is safe for concurrent use by multiple goroutines.
 type DaemonConnectionStruct struct {
-	Connection net.Conn
 	config.BobbitClientConfig
+
+	// infoMu guards info and infoFetchedAt
+	infoMu sync.Mutex
+	// info caches the description of the daemon for the compatibility check of every request,
+	// see infoCacheDuration
+	info          *payload.InfoResponse
+	infoFetchedAt time.Time
+
+	// tlsOnce loads tlsConfig on the first connection over TCP
+	tlsOnce   sync.Once
+	tlsConfig *tls.Config
+	tlsErr    error
 }
 
 // New creates and returns a new DaemonConnectionStruct initialized with the provided BobbitConfig.
@@ -24,43 +41,158 @@ func New(c config.BobbitClientConfig) *DaemonConnectionStruct {
 	}
 }
 
-// BuildPayload establishes a connection to the daemon's Unix socket and marshals the provided metadata
-// into the JobPayload. It returns an error if the connection fails or metadata marshalling fails.
-func (d *DaemonConnectionStruct) BuildPayload(p *payload.JobPayload, metadata any) (err error) {
-	conn, err := net.Dial("unix", d.BobbitConfig.SocketPath)
+// session is a single request to the daemon and the stream of its responses.
+type session struct {
+	conn    net.Conn
+	decoder *json.Decoder
+	// stop releases the context of the session
+	stop func() bool
+}
+
+// dial connects to the daemon's Unix socket, or over TCP with mutual TLS, see config.BobbitClientConfig.Address.
+// Transient errors, e.g. while the daemon is restarting, are retried up to DialRetries times.
+// Returns DaemonNotRunningError if every attempt failed.
+func (d *DaemonConnectionStruct) dial(ctx context.Context) (net.Conn, error) {
+	network, address, err := d.Endpoint()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	netDialer := &net.Dialer{Timeout: d.DialTimeout}
+	var dialer interface {
+		DialContext(ctx context.Context, network string, address string) (net.Conn, error)
+	} = netDialer
+	if network == "tcp" {
+		d.tlsOnce.Do(func() {
+			d.tlsConfig, d.tlsErr = config.LoadTLSConfig(d.TLSCertPath, d.TLSKeyPath, d.TLSCAPath, false)
+			if d.tlsErr == nil {
+				d.tlsConfig.ServerName = d.TLSServerName
+			}
+		})
+		if d.tlsErr != nil {
+			return nil, d.tlsErr
+		}
+		dialer = &tls.Dialer{NetDialer: netDialer, Config: d.tlsConfig}
+	}
+
+	for attempt := 0; ; attempt++ {
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= d.DialRetries || !isTransientDialError(err) {
+			return nil, &DaemonNotRunningError{NetError: err, Config: d.BobbitConfig, Address: network + "://" + address}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(d.DialRetryDelay):
+		}
+	}
+}
+
+// isTransientDialError reports whether the socket may accept a connection later.
+func isTransientDialError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EAGAIN)
+}
+
+// open connects to the daemon and sends the request with the provided metadata.
+// The deadline of ctx applies to every read and write of the session, and the session
+// is interrupted once ctx is done. The session must be closed by the caller.
+func (d *DaemonConnectionStruct) open(ctx context.Context, request payload.PayloadRequestEnum, metadata any) (*session, error) {
+	// Every daemon answers these, so they do not need the compatibility check
+	if request != payload.REQUEST_INFO && request != payload.REQUEST_VIBE_CHECK {
+		info, err := d.CheckCompatibility(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Supports(request) {
+			return nil, &IncompatibleDaemonError{Info: info, Request: request}
+		}
 	}
-	d.Connection = conn
 
+	p := payload.JobPayload{Request: request}
 	if err := p.MarshalMetadata(metadata); err != nil {
-		d.Connection.Close()
-		return err
+		return nil, err
 	}
 
-	return nil
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	s := &session{
+		conn:    conn,
+		decoder: json.NewDecoder(conn),
+		stop: context.AfterFunc(ctx, func() {
+			// Unblock pending reads and writes
+			conn.SetDeadline(time.Unix(1, 0))
+		}),
+	}
+
+	if err := json.NewEncoder(conn).Encode(p); err != nil {
+		s.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	return s, nil
 }
 
-// SendPayload encodes and sends the given target object over the established daemon connection.
-// It returns an error if encoding or writing fails.
-func (d *DaemonConnectionStruct) SendPayload(target any) error {
-	if err := json.NewEncoder(d.Connection).Encode(target); err != nil {
+// receive decodes the next response of the session into the provided target object.
+// An error response of the daemon is returned as ResponseError.
+func (s *session) receive(ctx context.Context, target any) error {
+	if err := decodePayload(s.decoder, target); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return err
 	}
 	return nil
 }
 
-// GetPayload decodes the response from the daemon connection into the provided target object.
-// It returns an error if decoding fails.
-func (d *DaemonConnectionStruct) GetPayload(target any) error {
+// Close closes the connection of the session.
+func (s *session) Close() error {
+	s.stop()
+	return s.conn.Close()
+}
+
+// roundTrip sends a request with the provided metadata and decodes its single response
+// into the provided target object.
+func (d *DaemonConnectionStruct) roundTrip(ctx context.Context, request payload.PayloadRequestEnum, metadata any, target any) error {
+	s, err := d.open(ctx, request, metadata)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.receive(ctx, target)
+}
+
+// decodePayload decodes the next response of a stream into the provided target object.
+// An error response of the daemon is returned as ResponseError.
+func decodePayload(decoder *json.Decoder, target any) error {
 	var raw json.RawMessage
-	if err := json.NewDecoder(d.Connection).Decode(&raw); err != nil {
+	if err := decoder.Decode(&raw); err != nil {
 		return err
 	}
 
 	var errorPayload payload.JobErrorResponse
 	if err := json.Unmarshal(raw, &errorPayload); err == nil && errorPayload.Error != "" {
-		return errors.New(errorPayload.Error)
+		return &ResponseError{errorPayload}
 	}
 
 	if err := json.Unmarshal(raw, target); err != nil {
@@ -72,35 +204,71 @@ func (d *DaemonConnectionStruct) GetPayload(target any) error {
 
 // TestConnection verifies if the daemon is reachable and responding.
 // It sends a simple vibe check payload and returns nil if successful.
-func (d *DaemonConnectionStruct) TestConnection() error {
-	p := payload.JobPayload{Request: payload.REQUEST_VIBE_CHECK}
-	if err := d.BuildPayload(&p, make(map[string]string, 1)); err != nil {
+func (d *DaemonConnectionStruct) TestConnection(ctx context.Context) error {
+	s, err := d.open(ctx, payload.REQUEST_VIBE_CHECK, make(map[string]string, 1))
+	if err != nil {
 		return err
 	}
-	defer d.Connection.Close()
+	defer s.Close()
 
-	if err := d.SendPayload(p); err != nil {
+	// The daemon only responds on error
+	var discard json.RawMessage
+	if err := s.receive(ctx, &discard); err != nil && err != io.EOF {
 		return err
 	}
 
 	return nil
 }
 
-// Status retrieves the detailed status of a specific job by its searchQuery (jobID or jobName by desc).
-// Returns the JobResponse containing job details or an error if the request fails.
-func (d *DaemonConnectionStruct) Status(searchQuery string) (payload.JobResponse, error) {
-	p := payload.JobPayload{Request: payload.REQUEST_STATUS}
-	if err := d.BuildPayload(&p, payload.JobSearchMetadata{Search: searchQuery}); err != nil {
-		return payload.JobResponse{}, err
+// Info retrieves the description of the daemon, including its protocol version, the
+// request types it handles and its features.
+func (d *DaemonConnectionStruct) Info(ctx context.Context) (payload.InfoResponse, error) {
+	var info payload.InfoResponse
+	if err := d.roundTrip(ctx, payload.REQUEST_INFO, make(map[string]string, 1), &info); err != nil {
+		return payload.InfoResponse{}, err
 	}
-	defer d.Connection.Close()
 
-	if err := d.SendPayload(p); err != nil {
-		return payload.JobResponse{}, err
+	return info, nil
+}
+
+// infoCacheDuration is how long the description of the daemon is reused by CheckCompatibility,
+// so a daemon restarted in the meantime, e.g. after an upgrade, is noticed.
+const infoCacheDuration = 30 * time.Second
+
+// CheckCompatibility verifies that the client and the daemon understand the protocol of
+// each other, and returns the description of the daemon. Daemons without REQUEST_INFO
+// are described as protocol version 1. The description is cached for infoCacheDuration.
+func (d *DaemonConnectionStruct) CheckCompatibility(ctx context.Context) (payload.InfoResponse, error) {
+	d.infoMu.Lock()
+	defer d.infoMu.Unlock()
+
+	if d.info == nil || time.Since(d.infoFetchedAt) > infoCacheDuration {
+		info, err := d.Info(ctx)
+		// Older daemons reject the request as unknown, or without error code if they are older
+		// than error codes. Other errors, e.g. forbidden, do not tell anything about the daemon.
+		var respErr *ResponseError
+		if errors.As(err, &respErr) && (respErr.Code == "" || respErr.Code == payload.ERROR_UNKNOWN_REQUEST) {
+			info, err = payload.InfoResponse{ProtocolVersion: 1, MinProtocolVersion: 1}, nil
+		}
+		if err != nil {
+			return payload.InfoResponse{}, err
+		}
+		d.info = &info
+		d.infoFetchedAt = time.Now()
+	}
+
+	if d.info.ProtocolVersion < payload.MinProtocolVersion || payload.ProtocolVersion < d.info.MinProtocolVersion {
+		return *d.info, &IncompatibleDaemonError{Info: *d.info}
 	}
 
+	return *d.info, nil
+}
+
+// Status retrieves the detailed status of a specific job by its searchQuery (jobID or jobName by desc).
+// Returns the JobResponse containing job details or an error if the request fails.
+func (d *DaemonConnectionStruct) Status(ctx context.Context, searchQuery string) (payload.JobResponse, error) {
 	var job payload.JobResponse
-	if err := d.GetPayload(&job); err != nil {
+	if err := d.roundTrip(ctx, payload.REQUEST_STATUS, payload.JobSearchMetadata{Search: searchQuery}, &job); err != nil {
 		return payload.JobResponse{}, err
 	}
 
@@ -109,60 +277,102 @@ func (d *DaemonConnectionStruct) Status(searchQuery string) (payload.JobResponse
 
 // Create submits a new job execution request to the daemon.
 // Takes JobDetailMetadata containing command, name, and other options.
-func (d *DaemonConnectionStruct) Create(req payload.JobDetailMetadata) (job payload.JobResponse, err error) {
-	p := payload.JobPayload{Request: payload.REQUEST_EXECUTE_JOB}
-	if err := d.BuildPayload(&p, req); err != nil {
-		return job, err
+func (d *DaemonConnectionStruct) Create(ctx context.Context, req payload.JobDetailMetadata) (payload.JobResponse, error) {
+	var job payload.JobResponse
+	if err := d.roundTrip(ctx, payload.REQUEST_EXECUTE_JOB, req, &job); err != nil {
+		return payload.JobResponse{}, err
 	}
-	defer d.Connection.Close()
 
-	if err := d.SendPayload(p); err != nil {
-		return job, err
-	}
+	return job, nil
+}
 
-	if err := d.GetPayload(&job); err != nil {
-		return job, err
+// Wait blocks until the latest job matching the ID or name has finished execution.
+// Returns the final JobResponse, or an error if the wait fails or ctx is done first.
+//
+// See: DaemonConnectionStruct.WaitJobs
+func (d *DaemonConnectionStruct) Wait(ctx context.Context, searchQuery string) (payload.JobResponse, error) {
+	resp, err := d.WaitJobs(ctx, payload.WaitRequestMetadata{Targets: []string{searchQuery}})
+	if err != nil {
+		return payload.JobResponse{}, err
+	}
+	if resp.TimedOut || len(resp.Jobs) == 0 {
+		return payload.JobResponse{}, context.DeadlineExceeded
 	}
 
-	return job, nil
+	return resp.Jobs[0], nil
 }
 
-// Wait blocks until the specified job has finished execution.
-// Returns the final JobResponse or an error if the wait fails.
+// WaitJobs blocks until the jobs of the request have finished execution according to
+// req.Mode, or until req.Deadline has passed, see WaitResponse.TimedOut.
+// If req.Deadline is nil, the deadline of ctx is used. Once ctx is cancelled, the wait is
+// cancelled and ctx.Err() is returned.
 //
-// See: DaemonConnectionStruct.Status
-func (d *DaemonConnectionStruct) Wait(searchQuery string) (job payload.JobResponse, err error) {
-	p := payload.JobPayload{Request: payload.REQUEST_WAIT}
-	if err = d.BuildPayload(&p, payload.JobSearchMetadata{Search: searchQuery}); err != nil {
-		return job, err
+// Daemons of protocol version 1 only wait for a single job, without metadata filter nor
+// req.Deadline. Other requests return ErrUnsupported with these daemons.
+func (d *DaemonConnectionStruct) WaitJobs(ctx context.Context, req payload.WaitRequestMetadata) (payload.WaitResponse, error) {
+	info, err := d.CheckCompatibility(ctx)
+	if err != nil {
+		return payload.WaitResponse{}, err
 	}
-	defer d.Connection.Close()
+	if info.ProtocolVersion < 2 {
+		return d.waitSingleJob(ctx, req)
+	}
+
+	waitCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		if req.Deadline == nil {
+			req.Deadline = &deadline
+		}
 
-	if err = d.SendPayload(p); err != nil {
-		return job, err
+		// The daemon responds with the unfinished jobs at the deadline, so leave it
+		// some time to respond before giving up on the connection.
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline.Add(d.DialTimeout))
+		defer cancel()
+		defer context.AfterFunc(ctx, func() {
+			if ctx.Err() == context.Canceled {
+				cancel()
+			}
+		})()
 	}
 
-	if err = d.GetPayload(&job); err != nil {
-		return job, err
+	var resp payload.WaitResponse
+	if err := d.roundTrip(waitCtx, payload.REQUEST_WAIT, req, &resp); err != nil {
+		if ctx.Err() != nil {
+			return payload.WaitResponse{}, ctx.Err()
+		}
+		return payload.WaitResponse{}, err
 	}
 
-	return job, nil
+	return resp, nil
 }
 
-// List retrieves a list of jobs based on the provided search criteria.
-func (d *DaemonConnectionStruct) List(req payload.JobSearchMetadata) ([]payload.JobResponse, error) {
-	p := payload.JobPayload{Request: payload.REQUEST_LIST}
-	if err := d.BuildPayload(&p, req); err != nil {
-		return []payload.JobResponse{}, err
+// waitSingleJob waits for the job of the request with a daemon of protocol version 1, which
+// only reads WaitRequestMetadata.Search and responds with the JobResponse of the job.
+func (d *DaemonConnectionStruct) waitSingleJob(ctx context.Context, req payload.WaitRequestMetadata) (payload.WaitResponse, error) {
+	targets := req.Targets
+	if req.Search != "" {
+		targets = append([]string{req.Search}, targets...)
+	}
+	if len(targets) != 1 || len(req.MetadataFilter) > 0 || req.Deadline != nil {
+		return payload.WaitResponse{}, fmt.Errorf("%w: daemons of protocol version 1 only wait for a single job without metadata filter nor deadline", ErrUnsupported)
 	}
-	defer d.Connection.Close()
 
-	if err := d.SendPayload(p); err != nil {
-		return []payload.JobResponse{}, err
+	var job payload.JobResponse
+	if err := d.roundTrip(ctx, payload.REQUEST_WAIT, payload.WaitRequestMetadata{Search: targets[0]}, &job); err != nil {
+		if ctx.Err() != nil {
+			return payload.WaitResponse{}, ctx.Err()
+		}
+		return payload.WaitResponse{}, err
 	}
 
+	return payload.WaitResponse{Jobs: []payload.JobResponse{job}}, nil
+}
+
+// List retrieves a list of jobs based on the provided search criteria.
+func (d *DaemonConnectionStruct) List(ctx context.Context, req payload.JobSearchMetadata) ([]payload.JobResponse, error) {
 	var jobs []payload.JobResponse
-	if err := d.GetPayload(&jobs); err != nil {
+	if err := d.roundTrip(ctx, payload.REQUEST_LIST, req, &jobs); err != nil {
 		return []payload.JobResponse{}, err
 	}
 
@@ -170,20 +380,11 @@ func (d *DaemonConnectionStruct) List(req payload.JobSearchMetadata) ([]payload.
 }
 
 // ListCount returns the number of jobs matching the activeOnly criteria.
-func (d *DaemonConnectionStruct) ListCount(req payload.JobSearchMetadata) (int, error) {
+func (d *DaemonConnectionStruct) ListCount(ctx context.Context, req payload.JobSearchMetadata) (int, error) {
 	req.NumberOnly = true
-	p := payload.JobPayload{Request: payload.REQUEST_LIST}
-	if err := d.BuildPayload(&p, req); err != nil {
-		return 0, err
-	}
-	defer d.Connection.Close()
-
-	if err := d.SendPayload(p); err != nil {
-		return 0, err
-	}
 
 	var count payload.JobResponseCount
-	if err := d.GetPayload(&count); err != nil {
+	if err := d.roundTrip(ctx, payload.REQUEST_LIST, req, &count); err != nil {
 		return 0, err
 	}
 
@@ -192,102 +393,131 @@ func (d *DaemonConnectionStruct) ListCount(req payload.JobSearchMetadata) (int,
 
 // Stop sends a request to stop a running job by its name or ID.
 // Returns the JobResponse of the stopped job or an error if the request fails.
-func (d *DaemonConnectionStruct) Stop(searchQuery string) (payload.JobResponse, error) {
-	p := payload.JobPayload{Request: payload.REQUEST_STOP}
-	req := payload.JobSearchMetadata{
-		Search: searchQuery,
-	}
-	if err := d.BuildPayload(&p, req); err != nil {
+func (d *DaemonConnectionStruct) Stop(ctx context.Context, searchQuery string) (payload.JobResponse, error) {
+	var job payload.JobResponse
+	if err := d.roundTrip(ctx, payload.REQUEST_STOP, payload.JobSearchMetadata{Search: searchQuery}, &job); err != nil {
 		return payload.JobResponse{}, err
 	}
-	defer d.Connection.Close()
 
-	if err := d.SendPayload(p); err != nil {
-		return payload.JobResponse{}, err
-	}
+	return job, nil
+}
 
+// FindJob attempts to locate a single job matching the provided query parameters.
+// This uses REQUEST_STATUS under the hood, similar to Status but with a full metadata struct.
+func (d *DaemonConnectionStruct) FindJob(ctx context.Context, query payload.JobSearchMetadata) (payload.JobResponse, error) {
 	var job payload.JobResponse
-	if err := d.GetPayload(&job); err != nil {
+	if err := d.roundTrip(ctx, payload.REQUEST_STATUS, query, &job); err != nil {
 		return payload.JobResponse{}, err
 	}
 
 	return job, nil
 }
 
-// FindJob attempts to locate a single job matching the provided query parameters.
-// This uses REQUEST_STATUS under the hood, similar to Status but with a full metadata struct.
-func (d *DaemonConnectionStruct) FindJob(query payload.JobSearchMetadata) (payload.JobResponse, error) {
-	p := payload.JobPayload{Request: payload.REQUEST_STATUS}
-	if err := d.BuildPayload(&p, query); err != nil {
-		return payload.JobResponse{}, err
+// Doctor retrieves the health report of the daemon, including the jobs
+// reconciled when the daemon was started.
+func (d *DaemonConnectionStruct) Doctor(ctx context.Context) (payload.DoctorResponse, error) {
+	var report payload.DoctorResponse
+	if err := d.roundTrip(ctx, payload.REQUEST_DOCTOR, make(map[string]string, 1), &report); err != nil {
+		return payload.DoctorResponse{}, err
 	}
-	defer d.Connection.Close()
 
-	if err := d.SendPayload(p); err != nil {
-		return payload.JobResponse{}, err
+	return report, nil
+}
+
+// Prune deletes the finished jobs matching the request together with their logfiles.
+// With req.DryRun, it only reports what would be deleted.
+func (d *DaemonConnectionStruct) Prune(ctx context.Context, req payload.PruneRequestMetadata) (payload.PruneResponse, error) {
+	var resp payload.PruneResponse
+	if err := d.roundTrip(ctx, payload.REQUEST_PRUNE, req, &resp); err != nil {
+		return payload.PruneResponse{}, err
 	}
 
+	return resp, nil
+}
+
+// UpdateMetadata merges the metadata into the latest job matching the ID or name.
+// Keys with a nil value are removed from the job metadata.
+func (d *DaemonConnectionStruct) UpdateMetadata(ctx context.Context, jobIDOrName string, metadata map[string]any) (*payload.JobResponse, error) {
+	req := payload.JobMetadataUpdateRequest{Search: jobIDOrName, Metadata: metadata}
+
 	var job payload.JobResponse
-	if err := d.GetPayload(&job); err != nil {
-		return payload.JobResponse{}, err
+	if err := d.roundTrip(ctx, payload.REQUEST_UPDATE_METADATA, req, &job); err != nil {
+		return nil, err
 	}
 
-	return job, nil
+	return &job, nil
+}
+
+// Watch subscribes to the lifecycle events of the jobs matching the filter.
+// The recent events requested by filter.History are sent first. Without filter.Follow,
+// the channel is closed after them, otherwise it is closed once ctx is done or the
+// daemon closes the stream.
+func (d *DaemonConnectionStruct) Watch(ctx context.Context, filter payload.WatchRequestMetadata) (<-chan payload.JobEvent, error) {
+	s, err := d.open(ctx, payload.REQUEST_WATCH, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp payload.WatchResponse
+	if err := s.receive(ctx, &resp); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	events := make(chan payload.JobEvent)
+	go func() {
+		defer close(events)
+		defer s.Close()
+
+		for {
+			var event payload.JobEvent
+			if err := s.receive(ctx, &event); err != nil {
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
 }
 
 // TailJobLogWithContext streams a job's log file in real-time with context support.
 // It takes a context for cancellation, job ID or search string, and a callback function.
 // The callback receives the log line as a string. If the callback returns an error, streaming stops.
 // Returns an error if the request fails, job is not found, or context is cancelled.
+//
+// See: DaemonConnectionStruct.TailJobLogLines
 func (d *DaemonConnectionStruct) TailJobLogWithContext(ctx context.Context, jobIDOrName string, follow bool, onLine func(string) error) error {
-	p := payload.JobPayload{Request: payload.REQUEST_TAIL_LOG}
-	search := payload.JobSearchMetadata{Search: jobIDOrName, Follow: follow}
-	if err := d.BuildPayload(&p, search); err != nil {
-		return err
-	}
-	defer d.Connection.Close()
+	return d.TailJobLogLines(ctx, jobIDOrName, follow, func(line payload.LogLine) error {
+		return onLine(line.Line)
+	})
+}
 
-	if err := d.SendPayload(p); err != nil {
+// TailJobLogLines streams a job's log file like TailJobLogWithContext, but the callback
+// receives the stream and timestamp of every line as well.
+func (d *DaemonConnectionStruct) TailJobLogLines(ctx context.Context, jobIDOrName string, follow bool, onLine func(payload.LogLine) error) error {
+	s, err := d.open(ctx, payload.REQUEST_TAIL_LOG, payload.JobSearchMetadata{Search: jobIDOrName, Follow: follow})
+	if err != nil {
 		return err
 	}
+	defer s.Close()
 
-	done := make(chan struct{})
-	defer close(done)
-
-	go func() {
-		select {
-		case <-ctx.Done():
-			d.Connection.Close()
-		case <-done:
-		}
-	}()
-
-	decoder := json.NewDecoder(d.Connection)
-	// Scan every single new request.
-	// Surely will cannot bumped to another connection...
 	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		var line map[string]string
-		if err := decoder.Decode(&line); err != nil {
+		var line payload.LogLine
+		if err := s.receive(ctx, &line); err != nil {
 			if err == io.EOF {
 				return nil
 			}
-			// Check if error is due to context cancellation
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
 			return err
 		}
 
-		if logLine, ok := line["line"]; ok {
-			if err := onLine(logLine); err != nil {
-				return err
-			}
+		if err := onLine(line); err != nil {
+			return err
 		}
 	}
 }
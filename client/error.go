@@ -1,16 +1,119 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/payload"
 )
 
+var (
+	// ErrJobNotFound is returned when no job matches the given ID or name.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrScheduleNotFound is returned when no schedule matches the given ID or name.
+	ErrScheduleNotFound = errors.New("schedule not found")
+	// ErrLogNotFound is returned when the logfile of the job does not exist.
+	ErrLogNotFound = errors.New("log not found")
+	// ErrDaemonUnavailable is returned when the daemon cannot be reached, see DaemonNotRunningError.
+	ErrDaemonUnavailable = errors.New("daemon unavailable")
+	// ErrInvalidRequest is returned when the daemon rejects the request, e.g. on invalid flags.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnknownRequest is returned when the daemon does not handle the request, e.g. it is older than the client.
+	ErrUnknownRequest = errors.New("unknown request")
+	// ErrForbidden is returned when the authorization policy of the daemon does not allow the request.
+	ErrForbidden = errors.New("forbidden")
+	// ErrConflict is returned when the current state of the job or schedule prevents the request.
+	ErrConflict = errors.New("conflict")
+	// ErrInternal is returned when the daemon failed to process the request, e.g. on database errors.
+	ErrInternal = errors.New("internal daemon error")
+	// ErrUnsupported is returned when the daemon is too old for the options of the request,
+	// e.g. waiting for several jobs with a daemon of protocol version 1.
+	ErrUnsupported = errors.New("unsupported by the daemon")
+	// ErrIncompatibleDaemon is returned when the daemon and the client do not understand each other, see IncompatibleDaemonError.
+	ErrIncompatibleDaemon = errors.New("incompatible daemon")
+	// ErrSubscriberDropped is returned when an event stream was ended because it was not read fast enough.
+	ErrSubscriberDropped = errors.New("subscriber dropped")
+)
+
+// errorCodes maps the error codes of the daemon to their sentinel error.
+var errorCodes = map[payload.ErrorCodeEnum]error{
+	payload.ERROR_JOB_NOT_FOUND:      ErrJobNotFound,
+	payload.ERROR_SCHEDULE_NOT_FOUND: ErrScheduleNotFound,
+	payload.ERROR_LOG_NOT_FOUND:      ErrLogNotFound,
+	payload.ERROR_INVALID_REQUEST:    ErrInvalidRequest,
+	payload.ERROR_UNKNOWN_REQUEST:    ErrUnknownRequest,
+	payload.ERROR_FORBIDDEN:          ErrForbidden,
+	payload.ERROR_CONFLICT:           ErrConflict,
+	payload.ERROR_INTERNAL:           ErrInternal,
+	payload.ERROR_SUBSCRIBER_DROPPED: ErrSubscriberDropped,
+}
+
 type DaemonNotRunningError struct {
 	NetError error
 	Config   config.BobbitConfig
+	// Address is the address of the daemon, e.g. `tcp://host:port`. If empty, it is the socket path of Config.
+	Address string
 }
 
 func (d *DaemonNotRunningError) Error() string {
-	return fmt.Sprintf("Cannot connect to %s. Is bobbitd running? Error: %v", d.Config.SocketPath, d.NetError)
+	address := d.Address
+	if address == "" || address == "unix://"+d.Config.SocketPath {
+		address = d.Config.SocketPath
+	}
+	return fmt.Sprintf("Cannot connect to %s. Is bobbitd running? Error: %v", address, d.NetError)
+}
+
+func (d *DaemonNotRunningError) Is(target error) bool {
+	return target == ErrDaemonUnavailable
+}
+
+func (d *DaemonNotRunningError) Unwrap() error {
+	return d.NetError
+}
+
+// IncompatibleDaemonError is returned before sending a request the daemon would not understand.
+type IncompatibleDaemonError struct {
+	// Info is the description of the daemon.
+	Info payload.InfoResponse
+	// Request is the request type the daemon does not handle, or 0 if the protocol versions are incompatible.
+	Request payload.PayloadRequestEnum
+}
+
+func (i *IncompatibleDaemonError) Error() string {
+	daemon := "bobbitd"
+	if i.Info.Version != "" {
+		daemon += " " + i.Info.Version
+	}
+
+	switch {
+	case i.Info.ProtocolVersion < payload.MinProtocolVersion:
+		return fmt.Sprintf("%s speaks protocol version %d, but this client needs at least version %d. Please upgrade bobbitd",
+			daemon, i.Info.ProtocolVersion, payload.MinProtocolVersion)
+	case payload.ProtocolVersion < i.Info.MinProtocolVersion:
+		return fmt.Sprintf("%s needs at least protocol version %d, but this client speaks version %d. Please upgrade the client",
+			daemon, i.Info.MinProtocolVersion, payload.ProtocolVersion)
+	}
+	return fmt.Sprintf("%s does not support the %s request. Please upgrade bobbitd",
+		daemon, payload.ParsePayloadRequest(i.Request))
+}
+
+func (i *IncompatibleDaemonError) Is(target error) bool {
+	return target == ErrIncompatibleDaemon
+}
+
+// ResponseError is an error response of the daemon.
+// Use errors.Is with the sentinel errors, e.g. ErrJobNotFound, to check its kind.
+type ResponseError struct {
+	payload.JobErrorResponse
+}
+
+func (r *ResponseError) Error() string {
+	return r.JobErrorResponse.Error
+}
+
+// Unwrap returns the sentinel error of the error code. Daemons without error
+// codes only send the message, so their errors do not match any sentinel.
+func (r *ResponseError) Unwrap() error {
+	return errorCodes[r.Code]
 }
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// sendScheduleRequest sends a REQUEST_SCHEDULE payload and decodes the response into target.
+func (d *DaemonConnectionStruct) sendScheduleRequest(ctx context.Context, req payload.ScheduleRequestMetadata, target any) error {
+	return d.roundTrip(ctx, payload.REQUEST_SCHEDULE, req, target)
+}
+
+// ScheduleAdd registers a new recurring job on the daemon.
+// Returns the created schedule including its first run time.
+func (d *DaemonConnectionStruct) ScheduleAdd(ctx context.Context, schedule payload.ScheduleDetail) (payload.ScheduleResponse, error) {
+	var resp payload.ScheduleResponse
+	req := payload.ScheduleRequestMetadata{Action: payload.SCHEDULE_ADD, Schedule: &schedule}
+	if err := d.sendScheduleRequest(ctx, req, &resp); err != nil {
+		return payload.ScheduleResponse{}, err
+	}
+	return resp, nil
+}
+
+// ScheduleList retrieves every schedule, including the paused ones.
+func (d *DaemonConnectionStruct) ScheduleList(ctx context.Context) ([]payload.ScheduleResponse, error) {
+	var resp []payload.ScheduleResponse
+	if err := d.sendScheduleRequest(ctx, payload.ScheduleRequestMetadata{Action: payload.SCHEDULE_LIST}, &resp); err != nil {
+		return []payload.ScheduleResponse{}, err
+	}
+	return resp, nil
+}
+
+// ScheduleRemove deletes a schedule by its name or ID. Jobs spawned by the schedule are kept.
+func (d *DaemonConnectionStruct) ScheduleRemove(ctx context.Context, searchQuery string) (payload.ScheduleResponse, error) {
+	return d.scheduleAction(ctx, payload.SCHEDULE_REMOVE, searchQuery)
+}
+
+// SchedulePause stops a schedule from spawning jobs until it is resumed.
+func (d *DaemonConnectionStruct) SchedulePause(ctx context.Context, searchQuery string) (payload.ScheduleResponse, error) {
+	return d.scheduleAction(ctx, payload.SCHEDULE_PAUSE, searchQuery)
+}
+
+// ScheduleResume lets a paused schedule spawn jobs again. Runs missed while paused are not caught up.
+func (d *DaemonConnectionStruct) ScheduleResume(ctx context.Context, searchQuery string) (payload.ScheduleResponse, error) {
+	return d.scheduleAction(ctx, payload.SCHEDULE_RESUME, searchQuery)
+}
+
+// ScheduleTrigger spawns a job from the schedule immediately, honouring its overlap policy.
+// Returns the JobResponse of the spawned job.
+func (d *DaemonConnectionStruct) ScheduleTrigger(ctx context.Context, searchQuery string) (payload.JobResponse, error) {
+	var job payload.JobResponse
+	req := payload.ScheduleRequestMetadata{Action: payload.SCHEDULE_TRIGGER, Search: searchQuery}
+	if err := d.sendScheduleRequest(ctx, req, &job); err != nil {
+		return payload.JobResponse{}, err
+	}
+	return job, nil
+}
+
+// scheduleAction sends a schedule action that returns the affected schedule.
+func (d *DaemonConnectionStruct) scheduleAction(ctx context.Context, action payload.ScheduleActionEnum, searchQuery string) (payload.ScheduleResponse, error) {
+	var resp payload.ScheduleResponse
+	req := payload.ScheduleRequestMetadata{Action: action, Search: searchQuery}
+	if err := d.sendScheduleRequest(ctx, req, &resp); err != nil {
+		return payload.ScheduleResponse{}, err
+	}
+	return resp, nil
+}
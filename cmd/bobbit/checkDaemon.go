@@ -1,27 +1,77 @@
 package main
 
 import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/client"
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/internal/lib"
 	"github.com/mplus-oss/bobbit.go/internal/shell"
 	"github.com/mplus-oss/bobbit.go/payload"
 	"github.com/spf13/cobra"
 )
 
 func RegisterDaemonCommand() {
-	cmd.AddCommand(&cobra.Command{
+	isRunning := &cobra.Command{
 		Use:   "is-running",
 		Short: "Check if bobbit daemon is running.",
 		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			p := payload.JobPayload{Request: payload.REQUEST_VIBE_CHECK}
-			if err := cli.BuildPayload(&p, make(map[string]string, 1)); err != nil {
-				shell.Fatalfln(3, "Failed to build payload: %v", err)
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
 			}
-			defer cli.Connection.Close()
 
-			if err := cli.SendPayload(p); err != nil {
+			if err := cli.TestConnection(cmd.Context()); err != nil {
 				shell.Fatalfln(3, "Failed to send payload to daemon: %v", err)
 			}
 			shell.Println("Daemon is running.")
+			if !verbose {
+				return
+			}
+
+			info, err := cli.CheckCompatibility(cmd.Context())
+			if err != nil && !errors.Is(err, client.ErrIncompatibleDaemon) {
+				shell.Fatalfln(3, "Failed to get daemon info: %v", err)
+			}
+
+			requests := make([]string, 0, len(info.Requests))
+			for _, request := range info.Requests {
+				requests = append(requests, payload.ParsePayloadRequest(request))
+			}
+			// Daemons of protocol version 1 do not list their requests
+			if info.ProtocolVersion < 2 {
+				requests = append(requests, "unknown")
+			}
+			features := make([]string, 0, len(info.Features))
+			for feature, enabled := range info.Features {
+				if enabled {
+					features = append(features, string(feature))
+				}
+			}
+			sort.Strings(features)
+
+			version := info.Version
+			if version == "" {
+				version = "unknown"
+			}
+			shell.Printf("  Daemon Version:  %s\n", version)
+			shell.Printf("  Client Version:  %s\n", config.Version)
+			shell.Printf("  Protocol:        %d (daemon), %d (client)\n", info.ProtocolVersion, payload.ProtocolVersion)
+			if !info.StartedAt.IsZero() {
+				shell.Printf("  Started:         %s (up %s)\n", info.StartedAt.Local().String(), lib.HumanizeDuration(time.Since(info.StartedAt)))
+			}
+			shell.Printf("  Requests:        %s\n", strings.Join(requests, ", "))
+			shell.Printf("  Features:        %s\n", strings.Join(features, ", "))
+			if err != nil {
+				shell.Fatalfln(1, "%v", err)
+			}
 		},
-	})
+	}
+	isRunning.Flags().BoolP("verbose", "v", false, "Print the version, protocol and features of the daemon")
+
+	cmd.AddCommand(isRunning)
 }
@@ -2,7 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/mplus-oss/bobbit.go/internal/lib"
 	"github.com/mplus-oss/bobbit.go/internal/shell"
 	"github.com/mplus-oss/bobbit.go/payload"
 	"github.com/spf13/cobra"
@@ -16,30 +22,228 @@ func RegisterCreateCommand() {
 		Run: func(cmd *cobra.Command, args []string) {
 			jobName, command := args[0], args[1:]
 
-			var metadata map[string]any
-			metadataStr, err := cmd.Flags().GetString("metadata")
+			req, err := parseJobFlags(cmd)
+			if err != nil {
+				shell.Fatalfln(8, "%v", err)
+			}
+			if err := parseDependencyFlags(cmd, &req); err != nil {
+				shell.Fatalfln(8, "%v", err)
+			}
+			req.JobName = jobName
+			req.Command = command
+
+			at, err := cmd.Flags().GetString("at")
 			if err != nil {
 				shell.Fatalfln(3, "%v", err)
 			}
-			if metadataStr != "" {
-				if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
-					shell.Fatalfln(8, "Metadata given is not valid JSON: %v", err)
+			if at != "" {
+				notBefore, err := time.Parse(time.RFC3339, at)
+				if err != nil {
+					shell.Fatalfln(8, "Invalid start time: %v", err)
 				}
+				req.NotBefore = &notBefore
 			}
-
-			req := payload.JobDetailMetadata{
-				JobName:  jobName,
-				Command:  command,
-				Metadata: metadata,
+			if req.Delay, err = cmd.Flags().GetDuration("in"); err != nil {
+				shell.Fatalfln(3, "%v", err)
 			}
 
-			job, err := cli.Create(req)
+			job, err := cli.Create(cmd.Context(), req)
 			if err != nil {
 				shell.Fatalfln(3, "Failed to create job: %v", err)
 			}
 			shell.Printfln("Job %s created! [%s]", job.JobName, job.ID)
 		},
 	}
-	create.Flags().StringP("metadata", "m", "", "JSON Metadata")
+	registerJobFlags(create)
+	registerDependencyFlags(create)
+	create.Flags().String("at", "", "Start the job not before this time (RFC3339, e.g., 2026-10-18T02:00:00Z)")
+	create.Flags().Duration("in", 0, "Start the job after this delay (e.g., 2h)")
+	create.MarkFlagsMutuallyExclusive("at", "in")
 	cmd.AddCommand(create)
 }
+
+// registerJobFlags registers the flags describing how a job is executed.
+func registerJobFlags(c *cobra.Command) {
+	c.Flags().StringP("metadata", "m", "", "JSON Metadata")
+	c.Flags().String("cwd", "", "Working directory of the job")
+	c.Flags().StringArrayP("env", "e", nil, "Set environment variable (e.g., -e KEY=VALUE). KEY alone takes the value from current environment")
+	c.Flags().StringArray("env-file", nil, "Read environment variables from a dotenv file")
+	c.Flags().Bool("clean-env", false, "Do not inherit the environment of the daemon")
+	c.Flags().Duration("timeout", 0, "Terminate the job if it runs longer than this duration (e.g., 30m)")
+	c.Flags().Duration("kill-grace", 0, "Time between SIGTERM and SIGKILL after the timeout (default from daemon)")
+	c.Flags().StringP("queue", "q", "", "Queue of the job. Jobs in the same queue share its concurrency limit")
+	c.Flags().Int("max-attempts", 1, "Total number of attempts when the command fails")
+	c.Flags().String("retry-backoff", string(payload.RETRY_BACKOFF_FIXED), "Delay growth between attempts (fixed or exponential)")
+	c.Flags().Duration("retry-delay", 0, "Delay before retrying the command (base delay for exponential backoff)")
+	c.Flags().Duration("retry-max-delay", 0, "Maximum delay between attempts")
+	c.Flags().String("log-max-size", "", "Maximum size of the job log (e.g., 100M). Default from daemon")
+	c.Flags().String("log-policy", "", "What to do when the log reaches its maximum size (rotate, truncate or stop). Default from daemon")
+	c.Flags().IntSlice("retry-on", nil, "Only retry on these exit codes (e.g., --retry-on 1,75). Default: any non-zero exit code")
+	c.Flags().Bool("retry-on-timeout", false, "Also retry the attempts terminated by --timeout")
+}
+
+// parseJobFlags builds the job detail from the flags registered by registerJobFlags.
+// The job name and command are left empty.
+func parseJobFlags(cmd *cobra.Command) (payload.JobDetailMetadata, error) {
+	var req payload.JobDetailMetadata
+
+	metadataStr, err := cmd.Flags().GetString("metadata")
+	if err != nil {
+		return req, err
+	}
+	if metadataStr != "" {
+		if err := json.Unmarshal([]byte(metadataStr), &req.Metadata); err != nil {
+			return req, fmt.Errorf("metadata given is not valid JSON: %w", err)
+		}
+	}
+
+	if req.WorkDir, err = cmd.Flags().GetString("cwd"); err != nil {
+		return req, err
+	}
+	if req.WorkDir != "" {
+		// The daemon does not share our working directory, so make it absolute.
+		if req.WorkDir, err = filepath.Abs(req.WorkDir); err != nil {
+			return req, fmt.Errorf("invalid working directory: %w", err)
+		}
+	}
+
+	if req.Env, err = parseEnvFlags(cmd); err != nil {
+		return req, err
+	}
+
+	cleanEnv, err := cmd.Flags().GetBool("clean-env")
+	if err != nil {
+		return req, err
+	}
+	inheritEnv := !cleanEnv
+	req.InheritEnv = &inheritEnv
+
+	if req.Timeout, err = cmd.Flags().GetDuration("timeout"); err != nil {
+		return req, err
+	}
+	if req.KillGracePeriod, err = cmd.Flags().GetDuration("kill-grace"); err != nil {
+		return req, err
+	}
+
+	if req.Queue, err = cmd.Flags().GetString("queue"); err != nil {
+		return req, err
+	}
+
+	logMaxSize, err := cmd.Flags().GetString("log-max-size")
+	if err != nil {
+		return req, err
+	}
+	if logMaxSize != "" {
+		if req.LogMaxSize, err = lib.ParseByteSize(logMaxSize); err != nil {
+			return req, err
+		}
+	}
+	logPolicy, err := cmd.Flags().GetString("log-policy")
+	if err != nil {
+		return req, err
+	}
+	req.LogPolicy = payload.LogPolicyEnum(logPolicy)
+
+	if req.Retry, err = parseRetryFlags(cmd); err != nil {
+		return req, err
+	}
+
+	return req, nil
+}
+
+// parseRetryFlags builds the retry policy from the retry flags.
+// It returns nil if the command should only be executed once.
+func parseRetryFlags(cmd *cobra.Command) (*payload.RetryPolicy, error) {
+	maxAttempts, err := cmd.Flags().GetInt("max-attempts")
+	if err != nil {
+		return nil, err
+	}
+	if maxAttempts <= 1 {
+		return nil, nil
+	}
+
+	backoff, err := cmd.Flags().GetString("retry-backoff")
+	if err != nil {
+		return nil, err
+	}
+	delay, err := cmd.Flags().GetDuration("retry-delay")
+	if err != nil {
+		return nil, err
+	}
+	maxDelay, err := cmd.Flags().GetDuration("retry-max-delay")
+	if err != nil {
+		return nil, err
+	}
+	retryOn, err := cmd.Flags().GetIntSlice("retry-on")
+	if err != nil {
+		return nil, err
+	}
+	retryOnTimeout, err := cmd.Flags().GetBool("retry-on-timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	return &payload.RetryPolicy{
+		MaxAttempts:      maxAttempts,
+		Backoff:          payload.RetryBackoffEnum(backoff),
+		Delay:            delay,
+		MaxDelay:         maxDelay,
+		RetryOnExitCodes: retryOn,
+		RetryOnTimeout:   retryOnTimeout,
+	}, nil
+}
+
+// parseEnvFlags merges --env-file and --env flags into a single map.
+// Variables given by --env take precedence over the ones from --env-file.
+func parseEnvFlags(cmd *cobra.Command) (map[string]string, error) {
+	env := map[string]string{}
+
+	envFiles, err := cmd.Flags().GetStringArray("env-file")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range envFiles {
+		fileEnv, err := lib.ParseEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileEnv {
+			env[k] = v
+		}
+	}
+
+	envVars, err := cmd.Flags().GetStringArray("env")
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range envVars {
+		k, v, found := strings.Cut(kv, "=")
+		if !found {
+			v = os.Getenv(k)
+		}
+		env[k] = v
+	}
+
+	return env, nil
+}
+
+// registerDependencyFlags registers the flags of the jobs the job depends on. Scheduled jobs
+// can not depend on other jobs, so they are not part of registerJobFlags.
+func registerDependencyFlags(c *cobra.Command) {
+	c.Flags().StringSlice("depends-on", nil, "Start the job after these jobs (ID or name) are finished (e.g., --depends-on build,test)")
+	c.Flags().String("condition", string(payload.DEPENDENCY_ON_SUCCESS), "Required outcome of --depends-on jobs (success, completion or failure)")
+}
+
+// parseDependencyFlags sets the dependencies of the job from the flags registered by registerDependencyFlags.
+func parseDependencyFlags(cmd *cobra.Command, req *payload.JobDetailMetadata) error {
+	var err error
+	if req.DependsOn, err = cmd.Flags().GetStringSlice("depends-on"); err != nil {
+		return err
+	}
+	condition, err := cmd.Flags().GetString("condition")
+	if err != nil {
+		return err
+	}
+	req.DependencyCondition = payload.DependencyConditionEnum(condition)
+	return nil
+}
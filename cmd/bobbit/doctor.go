@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/internal/lib"
+	"github.com/mplus-oss/bobbit.go/internal/shell"
+	"github.com/mplus-oss/bobbit.go/payload"
+	"github.com/spf13/cobra"
+)
+
+func RegisterDoctorCommand() {
+	doctor := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the health of the daemon",
+		Long:  "Check the health of the daemon. It reports the jobs that were left running by a previous daemon and how they were reconciled.",
+		Run: func(cmd *cobra.Command, args []string) {
+			toJson, err := cmd.Flags().GetBool("to-json")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			report, err := cli.Doctor(cmd.Context())
+			if err != nil {
+				shell.Fatalfln(3, "Failed to get daemon report: %v", err)
+			}
+
+			if toJson {
+				byteStr, err := json.Marshal(report)
+				if err != nil {
+					shell.Fatalln(3, err.Error())
+					return
+				}
+				shell.Println(string(byteStr))
+				return
+			}
+
+			shell.Printf("Daemon Report\n")
+			shell.Printf("------------------------\n")
+			shell.Printf("  Started:      %s (up %s)\n", report.StartedAt.Local().String(), lib.HumanizeDuration(time.Since(report.StartedAt)))
+			shell.Printf("  Running Jobs: %d\n", report.RunningJobs)
+
+			if len(report.Reconciled) == 0 {
+				shell.Printf("  Reconciled:   none, previous daemon exited cleanly\n")
+			} else {
+				shell.Printf("  Reconciled:\n")
+				w := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+				fmt.Fprintln(w, "    ID (Short)\tName\tPID\tAction\tReason")
+				for _, job := range report.Reconciled {
+					fmt.Fprintf(w, "    %s\t%s\t%d\t%s\t%s\n", job.ID[:16], job.JobName, job.PID, job.Action, job.Reason)
+				}
+				if err := w.Flush(); err != nil {
+					shell.Fatalfln(3, "Failed to print table: %v", err)
+				}
+			}
+
+			if len(report.Untracked) == 0 {
+				return
+			}
+			shell.Printf("  Untracked:\n")
+			for _, job := range report.Untracked {
+				shell.Printf("    - %s (%s) [%s]\n", job.JobName, job.ID[:16], payload.ParseJobStatus(job.Status))
+			}
+			shell.Fatalln(1, "Some running jobs are not tracked by the daemon.")
+		},
+	}
+	doctor.Flags().BoolP("to-json", "j", false, "Print the report to stringify JSON")
+
+	cmd.AddCommand(doctor)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/internal/shell"
+	"github.com/mplus-oss/bobbit.go/payload"
+	"github.com/spf13/cobra"
+)
+
+func RegisterEventsCommand() {
+	events := &cobra.Command{
+		Use:   "events",
+		Short: "Show lifecycle events of jobs",
+		Long:  "Show the recent lifecycle events of jobs (created, started, finished, stopped, ...). Use --follow to stream new events.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var req payload.WatchRequestMetadata
+			var err error
+
+			if req.Follow, err = cmd.Flags().GetBool("follow"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if req.History, err = cmd.Flags().GetInt("history"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if req.JobName, err = cmd.Flags().GetString("name"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if req.IDPrefix, err = cmd.Flags().GetString("id"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if req.MetadataFilter, err = cmd.Flags().GetStringToString("metadata"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			types, err := cmd.Flags().GetStringSlice("type")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			for _, t := range types {
+				req.Types = append(req.Types, payload.JobEventTypeEnum(t))
+			}
+			toJson, err := cmd.Flags().GetBool("to-json")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigChan
+				cancel()
+			}()
+
+			stream, err := cli.Watch(ctx, req)
+			if err != nil {
+				shell.Fatalfln(3, "Failed to watch events: %v", err)
+			}
+
+			for event := range stream {
+				if toJson {
+					byteStr, err := json.Marshal(event)
+					if err != nil {
+						shell.Fatalln(3, err.Error())
+					}
+					shell.Println(string(byteStr))
+					continue
+				}
+
+				line := fmt.Sprintf("%s  %-16s  %s  %s  %s",
+					event.Timestamp.Local().Format(time.RFC3339), event.Type,
+					event.ID[:16], event.JobName, payload.ParseJobStatus(event.Status))
+				if event.Status.IsFinished() {
+					line += fmt.Sprintf(" (exit code %d)", event.ExitCode)
+				}
+				shell.Println(line)
+			}
+		},
+	}
+	events.Flags().BoolP("follow", "f", false, "Stream new events until interrupted")
+	events.Flags().IntP("history", "n", 10, "Number of recent events to show")
+	events.Flags().String("name", "", "Only show events of jobs with this name (SQL LIKE wildcards are supported)")
+	events.Flags().String("id", "", "Only show events of jobs with this ID prefix")
+	events.Flags().StringToStringP("metadata", "m", nil, "Filter jobs by metadata (e.g., -m 'key1=value1,key2=%%value2%%')")
+	events.Flags().StringSlice("type", nil, "Only show these event types (created, status-changed, started, finished, stopped, metadata-updated)")
+	events.Flags().BoolP("to-json", "j", false, "Print every event as stringify JSON")
+
+	cmd.AddCommand(events)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"text/tabwriter"
 	"time"
 
@@ -25,6 +26,10 @@ func RegisterListCommand() {
 			if err != nil {
 				shell.Fatalfln(3, "%v", err)
 			}
+			queuedOnly, err := cmd.Flags().GetBool("queued")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
 			orderDesc, err := cmd.Flags().GetBool("desc")
 			if err != nil {
 				shell.Fatalfln(3, "%v", err)
@@ -58,11 +63,12 @@ func RegisterListCommand() {
 				NumberOnly:     jobNumberOnly,
 				OrderDesc:      orderDesc,
 				FinishOnly:     finishOnly,
+				QueuedOnly:     queuedOnly,
 				MetadataFilter: metadataFilter,
 			}
 
 			if jobNumberOnly {
-				count, err := cli.ListCount(req)
+				count, err := cli.ListCount(cmd.Context(), req)
 				if err != nil {
 					shell.Fatalfln(3, "Failed to get job count: %v", err)
 				}
@@ -70,7 +76,7 @@ func RegisterListCommand() {
 				return
 			}
 
-			jobs, err := cli.List(req)
+			jobs, err := cli.List(cmd.Context(), req)
 			if err != nil {
 				shell.Fatalfln(3, "Failed to list jobs: %v", err)
 			}
@@ -86,16 +92,32 @@ func RegisterListCommand() {
 			}
 
 			w := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
-			fmt.Fprintln(w, "Start\tUpdate\tID (Short)\tName\tStatus\tExit Code")
+			fmt.Fprintln(w, "Start\tUpdate\tID (Short)\tName\tStatus\tExit Code\tQueue\tPosition\tStarts At")
 			for _, job := range jobs {
+				queue := job.Queue
+				if queue == "" {
+					queue = "default"
+				}
+				position := "-"
+				if job.QueuePosition > 0 {
+					position = strconv.Itoa(job.QueuePosition)
+				}
+				startsAt := "-"
+				if job.NotBefore != nil {
+					startsAt = job.NotBefore.Format(time.RFC3339)
+				}
+
 				fmt.Fprintf(
-					w, "%v\t%v\t%s\t%s\t%s\t%d\n",
+					w, "%v\t%v\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
 					job.CreatedAt.Format(time.RFC3339),
 					job.UpdatedAt.Format(time.RFC3339),
 					job.ID[:16],
 					job.JobName,
 					payload.ParseJobStatus(job.Status),
 					job.ExitCode,
+					queue,
+					position,
+					startsAt,
 				)
 			}
 			if err := w.Flush(); err != nil {
@@ -105,7 +127,8 @@ func RegisterListCommand() {
 	}
 
 	list.Flags().BoolP("active-only", "a", false, "Filters the list to show only jobs with a running or active status")
-	list.Flags().BoolP("finish-only", "f", false, "Filters the list to show only jobs with a finish or failed status")
+	list.Flags().BoolP("finish-only", "f", false, "Filters the list to show only finished jobs, including the stopped, skipped and lost ones")
+	list.Flags().BoolP("queued", "q", false, "Filters the list to show only jobs waiting in the queue")
 	list.Flags().Bool("desc", false, "Orders the list of jobs in descending order")
 	list.Flags().IntP("count", "n", 0, "Sets a maximum number of jobs to return")
 	list.Flags().IntP("page", "p", 0, "Create pagination of jobs based on limit option")
@@ -5,25 +5,46 @@ import (
 
 	"github.com/mplus-oss/bobbit.go/client"
 	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/internal/shell"
 	"github.com/spf13/cobra"
 )
 
 var (
 	cli = client.New(config.NewClient())
 	cmd = &cobra.Command{
-		Use:   "bobbit",
-		Short: "Simply \"yet\" UNIX Socket based job runner",
+		Use:     "bobbit",
+		Short:   "Simply \"yet\" UNIX Socket based job runner",
+		Version: config.Version,
 	}
 )
 
 func init() {
+	config.RegisterFlags(cmd.PersistentFlags(), config.ClientSettings())
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		loader, err := config.LoadFlags(cmd.Flags(), config.ClientSettings())
+		if err != nil {
+			shell.Fatalfln(3, "%v", err)
+		}
+		c, err := loader.Client()
+		if err != nil {
+			shell.Fatalfln(3, "Invalid configuration:\n%v", err)
+		}
+		cli = client.New(c)
+	}
+
 	RegisterCreateCommand()
+	RegisterRunCommand()
 	RegisterDaemonCommand()
 	RegisterListCommand()
 	RegisterWaitCommand()
 	RegisterStatusCommand()
 	RegisterStopCommand()
 	RegisterTailCommand()
+	RegisterScheduleCommand()
+	RegisterDoctorCommand()
+	RegisterPruneCommand()
+	RegisterEventsCommand()
+	RegisterMetadataCommand()
 }
 
 func main() {
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/mplus-oss/bobbit.go/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+func RegisterMetadataCommand() {
+	metadata := &cobra.Command{
+		Use:   "metadata <job_id_or_name> <json>",
+		Short: "Update the metadata of a job",
+		Long:  "Merge a JSON object into the metadata of a job. Keys with a null value are removed.",
+		Example: `  bobbit metadata build '{"stage":"deploy"}'
+  bobbit metadata build '{"stage":null}'`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var metadata map[string]any
+			if err := json.Unmarshal([]byte(args[1]), &metadata); err != nil {
+				shell.Fatalfln(8, "Metadata given is not a valid JSON object: %v", err)
+			}
+
+			job, err := cli.UpdateMetadata(cmd.Context(), args[0], metadata)
+			if err != nil {
+				shell.Fatalfln(3, "Failed to update metadata: %v", err)
+			}
+
+			byteStr, err := json.Marshal(job.Metadata)
+			if err != nil {
+				shell.Fatalln(3, err.Error())
+			}
+			shell.Printfln("Metadata of job %s [%s] updated: %s", job.JobName, job.ID, string(byteStr))
+		},
+	}
+
+	cmd.AddCommand(metadata)
+}
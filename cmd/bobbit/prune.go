@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mplus-oss/bobbit.go/internal/lib"
+	"github.com/mplus-oss/bobbit.go/internal/shell"
+	"github.com/mplus-oss/bobbit.go/payload"
+	"github.com/spf13/cobra"
+)
+
+func RegisterPruneCommand() {
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old finished jobs and their logs",
+		Long: "Delete old finished jobs and their logs. A job is deleted if it was finished longer than --older-than ago, " +
+			"or if it is older than the latest --keep jobs with the same name. Jobs that an unfinished job depends on are kept.",
+		Example: "  bobbit prune --older-than 30d --status finished --dry-run",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var req payload.PruneRequestMetadata
+
+			olderThan, err := cmd.Flags().GetString("older-than")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if olderThan != "" {
+				if req.OlderThan, err = lib.ParseDuration(olderThan); err != nil {
+					shell.Fatalfln(8, "Invalid --older-than: %v", err)
+				}
+			}
+
+			statuses, err := cmd.Flags().GetStringSlice("status")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			for _, name := range statuses {
+				status, err := payload.ParseJobStatusName(name)
+				if err != nil {
+					shell.Fatalfln(8, "Invalid --status: %v", err)
+				}
+				req.Statuses = append(req.Statuses, status)
+			}
+
+			if req.MaxPerName, err = cmd.Flags().GetInt("keep"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if req.DryRun, err = cmd.Flags().GetBool("dry-run"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if req.OlderThan <= 0 && req.MaxPerName <= 0 {
+				shell.Fatalfln(8, "Either --older-than or --keep is required")
+			}
+
+			toJson, err := cmd.Flags().GetBool("to-json")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			resp, err := cli.Prune(cmd.Context(), req)
+			if err != nil {
+				shell.Fatalfln(3, "Failed to prune jobs: %v", err)
+			}
+
+			if toJson {
+				byteStr, err := json.Marshal(resp)
+				if err != nil {
+					shell.Fatalln(3, err.Error())
+					return
+				}
+				shell.Println(string(byteStr))
+				return
+			}
+
+			if len(resp.Jobs) == 0 {
+				shell.Println("Nothing to prune.")
+				return
+			}
+
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if verbose || resp.DryRun {
+				w := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+				fmt.Fprintln(w, "ID (Short)\tName\tStatus")
+				for _, job := range resp.Jobs {
+					fmt.Fprintf(w, "%s\t%s\t%s\n", job.ID[:16], job.JobName, payload.ParseJobStatus(job.Status))
+				}
+				if err := w.Flush(); err != nil {
+					shell.Fatalfln(3, "Failed to print table: %v", err)
+				}
+			}
+
+			if resp.DryRun {
+				shell.Printfln("Would delete %d jobs and reclaim %s.", len(resp.Jobs), lib.HumanizeBytes(resp.ReclaimedBytes))
+				return
+			}
+			shell.Printfln("Deleted %d jobs and reclaimed %s.", len(resp.Jobs), lib.HumanizeBytes(resp.ReclaimedBytes))
+		},
+	}
+	prune.Flags().String("older-than", "", "Delete jobs finished longer than this duration ago (e.g., 30d, 12h)")
+	prune.Flags().StringSlice("status", nil, "Only delete jobs with these statuses (finished, failed, stopped, timed-out, skipped, lost). Default: any final status")
+	prune.Flags().Int("keep", 0, "Keep only the latest N finished jobs of every job name")
+	prune.Flags().Bool("dry-run", false, "Show what would be deleted without deleting anything")
+	prune.Flags().BoolP("verbose", "v", false, "List the deleted jobs")
+	prune.Flags().BoolP("to-json", "j", false, "Print the result to stringify JSON")
+
+	cmd.AddCommand(prune)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mplus-oss/bobbit.go/internal/shell"
+	"github.com/mplus-oss/bobbit.go/payload"
+	"github.com/spf13/cobra"
+)
+
+func RegisterRunCommand() {
+	run := &cobra.Command{
+		Use:   "run <job_name> -- <command>",
+		Short: "Create a job, stream its output and exit with its exit code",
+		Long: "Create a job, stream its output until it is finished and exit with the exit code of the job " +
+			"(128+signal if it was killed by a signal). Interrupting stops the job, unless --detach-on-interrupt is set.",
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			jobName, command := args[0], args[1:]
+
+			req, err := parseJobFlags(cmd)
+			if err != nil {
+				shell.Fatalfln(8, "%v", err)
+			}
+			if err := parseDependencyFlags(cmd, &req); err != nil {
+				shell.Fatalfln(8, "%v", err)
+			}
+			req.JobName = jobName
+			req.Command = command
+
+			detach, err := cmd.Flags().GetBool("detach-on-interrupt")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			timestamps, err := cmd.Flags().GetBool("timestamps")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			job, err := cli.Create(cmd.Context(), req)
+			if err != nil {
+				shell.Fatalfln(3, "Failed to create job: %v", err)
+			}
+			shell.Printfln("Job %s created! [%s]", job.JobName, job.ID)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			detached := make(chan struct{})
+			go func() {
+				<-sigChan
+				if detach {
+					close(detached)
+					cancel()
+					return
+				}
+
+				// Keep streaming until the job is stopped, so its final output and exit code are shown
+				shell.Printfln("Stopping job %s [%s]...", job.JobName, job.ID)
+				if _, err := cli.Stop(context.Background(), job.ID); err != nil {
+					shell.Printfln("Failed to stop job: %v", err)
+				}
+
+				<-sigChan
+				shell.Fatalfln(130, "Interrupted, job %s [%s] may still be running.", job.JobName, job.ID)
+			}()
+
+			err = cli.TailJobLogLines(ctx, job.ID, true, func(line payload.LogLine) error {
+				printLogLine(line, timestamps)
+				return nil
+			})
+			select {
+			case <-detached:
+				shell.Fatalfln(130, "Detached, job %s [%s] keeps running.", job.JobName, job.ID)
+			default:
+			}
+			if err != nil {
+				shell.Fatalfln(3, "Failed to stream job log: %v", err)
+			}
+
+			// The log stream also ends if the daemon is restarted, so make sure the job is finished
+			job, err = cli.Status(ctx, job.ID)
+			if err != nil {
+				shell.Fatalfln(3, "Failed to get job status: %v", err)
+			}
+			if !job.Status.IsFinished() {
+				if job, err = cli.Wait(ctx, job.ID); err != nil {
+					shell.Fatalfln(3, "Failed to wait for job: %v", err)
+				}
+			}
+
+			exitCode := jobExitCode(job)
+			if exitCode != 0 {
+				shell.Printfln("Job %s [%s] is finished with status \"%s\" (exit code %d).",
+					job.JobName, job.ID, payload.ParseJobStatus(job.Status), exitCode)
+			}
+			os.Exit(exitCode)
+		},
+	}
+	registerJobFlags(run)
+	registerDependencyFlags(run)
+	run.Flags().Bool("detach-on-interrupt", false, "Leave the job running when interrupted instead of stopping it")
+	run.Flags().BoolP("timestamps", "t", false, "Show the time each line was written")
+	cmd.AddCommand(run)
+}
+
+// jobExitCode returns the exit code of a finished job for the shell. Jobs killed by a signal
+// already have 128+signal as exit code. Jobs that never exited by themselves return 1.
+func jobExitCode(job payload.JobResponse) int {
+	if job.ExitCode < 0 {
+		return 1
+	}
+	return job.ExitCode
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/internal/shell"
+	"github.com/mplus-oss/bobbit.go/payload"
+	"github.com/spf13/cobra"
+)
+
+func RegisterScheduleCommand() {
+	schedule := &cobra.Command{
+		Use:   "schedule",
+		Short: "Manage recurring jobs",
+	}
+
+	add := &cobra.Command{
+		Use:   "add <name> <expression> -- <command>",
+		Short: "Create new schedule (e.g., schedule add backup '0 3 * * *' -- ./backup.sh)",
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			name, expression, command := args[0], args[1], args[2:]
+
+			job, err := parseJobFlags(cmd)
+			if err != nil {
+				shell.Fatalfln(8, "%v", err)
+			}
+			job.Command = command
+			if job.JobName, err = cmd.Flags().GetString("job-name"); err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			overlap, err := cmd.Flags().GetString("overlap")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			paused, err := cmd.Flags().GetBool("paused")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			s, err := cli.ScheduleAdd(cmd.Context(), payload.ScheduleDetail{
+				Name:          name,
+				Expression:    expression,
+				OverlapPolicy: payload.OverlapPolicyEnum(overlap),
+				Paused:        paused,
+				Job:           job,
+			})
+			if err != nil {
+				shell.Fatalfln(3, "Failed to create schedule: %v", err)
+			}
+			shell.Printfln("Schedule %s created! [%s] Next run: %s", s.Name, s.ID, formatScheduleTime(s.NextRunAt))
+		},
+	}
+	registerJobFlags(add)
+	add.Flags().String("job-name", "", "Name of the spawned jobs (default is the schedule name)")
+	add.Flags().String("overlap", string(payload.OVERLAP_SKIP), "What to do when the previous job is still active (skip, queue or allow)")
+	add.Flags().Bool("paused", false, "Create the schedule in paused state")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List of schedule",
+		Run: func(cmd *cobra.Command, args []string) {
+			toJson, err := cmd.Flags().GetBool("to-json")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			schedules, err := cli.ScheduleList(cmd.Context())
+			if err != nil {
+				shell.Fatalfln(3, "Failed to list schedules: %v", err)
+			}
+
+			if toJson {
+				byteStr, err := json.Marshal(schedules)
+				if err != nil {
+					shell.Fatalln(3, err.Error())
+					return
+				}
+				shell.Println(string(byteStr))
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "ID (Short)\tName\tExpression\tOverlap\tState\tLast Run\tNext Run\tLast Job")
+			for _, s := range schedules {
+				state := "active"
+				if s.Paused {
+					state = "paused"
+				}
+				lastJob := "-"
+				if len(s.LastJobID) >= 16 {
+					lastJob = s.LastJobID[:16]
+				}
+
+				fmt.Fprintf(
+					w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					s.ID[:16],
+					s.Name,
+					s.Expression,
+					s.OverlapPolicy,
+					state,
+					formatScheduleTime(s.LastRunAt),
+					formatScheduleTime(s.NextRunAt),
+					lastJob,
+				)
+			}
+			if err := w.Flush(); err != nil {
+				shell.Fatalfln(3, "Failed to print table: %v", err)
+			}
+		},
+	}
+	list.Flags().BoolP("to-json", "j", false, "Print the list to stringify JSON")
+
+	remove := &cobra.Command{
+		Use:     "rm <schedule_id|schedule_name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove a schedule. Spawned jobs are kept",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := cli.ScheduleRemove(cmd.Context(), args[0])
+			if err != nil {
+				shell.Fatalfln(3, "Failed to remove schedule: %v", err)
+			}
+			shell.Printfln("Schedule %s removed! [%s]", s.Name, s.ID)
+		},
+	}
+
+	pause := &cobra.Command{
+		Use:   "pause <schedule_id|schedule_name>",
+		Short: "Stop a schedule from spawning jobs",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := cli.SchedulePause(cmd.Context(), args[0])
+			if err != nil {
+				shell.Fatalfln(3, "Failed to pause schedule: %v", err)
+			}
+			shell.Printfln("Schedule %s paused! [%s]", s.Name, s.ID)
+		},
+	}
+
+	resume := &cobra.Command{
+		Use:   "resume <schedule_id|schedule_name>",
+		Short: "Let a paused schedule spawn jobs again",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := cli.ScheduleResume(cmd.Context(), args[0])
+			if err != nil {
+				shell.Fatalfln(3, "Failed to resume schedule: %v", err)
+			}
+			shell.Printfln("Schedule %s resumed! [%s] Next run: %s", s.Name, s.ID, formatScheduleTime(s.NextRunAt))
+		},
+	}
+
+	trigger := &cobra.Command{
+		Use:   "trigger <schedule_id|schedule_name>",
+		Short: "Spawn a job from the schedule now",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			job, err := cli.ScheduleTrigger(cmd.Context(), args[0])
+			if err != nil {
+				shell.Fatalfln(3, "Failed to trigger schedule: %v", err)
+			}
+			shell.Printfln("Job %s created! [%s]", job.JobName, job.ID)
+		},
+	}
+
+	schedule.AddCommand(add, list, remove, pause, resume, trigger)
+	cmd.AddCommand(schedule)
+}
+
+// formatScheduleTime formats the optional time of a schedule in local time.
+func formatScheduleTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
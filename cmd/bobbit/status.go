@@ -3,6 +3,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"os/user"
+	"sort"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/mplus-oss/bobbit.go/internal/lib"
@@ -23,7 +27,7 @@ func RegisterStatusCommand() {
 				shell.Fatalfln(3, "%v", err)
 			}
 
-			job, err := cli.Status(args[0])
+			job, err := cli.Status(cmd.Context(), args[0])
 			if err != nil {
 				shell.Fatalfln(3, "Failed to get job status: %v", err)
 			}
@@ -52,7 +56,100 @@ func RegisterStatusCommand() {
 			shell.Printf("  ID:        %s\n", job.ID)
 			shell.Printf("  Status:    %s\n", payload.ParseJobStatus(job.Status))
 			shell.Printf("  Exit Code: %d\n", job.ExitCode)
+			if job.TerminationReason != "" {
+				reason := string(job.TerminationReason)
+				if job.Signal > 0 {
+					reason = fmt.Sprintf("%s (signal %d, %v)", reason, job.Signal, syscall.Signal(job.Signal))
+				}
+				shell.Printf("  Reason:    %s\n", reason)
+			}
 			shell.Printf("  Time:      %s\n", timeStr)
+			if job.NotBefore != nil {
+				startsAt := job.NotBefore.Local().String()
+				if job.Status == payload.JOB_SCHEDULED {
+					startsAt = fmt.Sprintf("%s (in %s)", startsAt, lib.HumanizeDuration(time.Until(*job.NotBefore)))
+				}
+				shell.Printf("  Starts At: %s\n", startsAt)
+			}
+			if job.Queue != "" || job.QueuePosition > 0 {
+				queue := job.Queue
+				if queue == "" {
+					queue = "default"
+				}
+				if job.QueuePosition > 0 {
+					queue = fmt.Sprintf("%s (position %d)", queue, job.QueuePosition)
+				}
+				shell.Printf("  Queue:     %s\n", queue)
+			}
+			if job.Timeout > 0 {
+				shell.Printf("  Timeout:   %s\n", lib.HumanizeDuration(job.Timeout))
+			}
+
+			workDir := job.WorkDir
+			if workDir == "" {
+				workDir = "(daemon working directory)"
+			}
+			shell.Printf("  Work Dir:  %s\n", workDir)
+
+			if owner := job.Owner; owner != nil {
+				ownerName := fmt.Sprintf("uid %d", owner.UID)
+				if u, err := user.LookupId(strconv.Itoa(owner.UID)); err == nil {
+					ownerName = u.Username
+				}
+				shell.Printf("  Owner:     %s (%d:%d)\n", ownerName, owner.UID, owner.GID)
+			}
+
+			envMode := "inherited from daemon"
+			if !job.ShouldInheritEnv() {
+				envMode = "clean"
+			}
+			shell.Printf("  Env:       %s\n", envMode)
+			if len(job.Env) > 0 {
+				keys := make([]string, 0, len(job.Env))
+				for k := range job.Env {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+
+				for _, k := range keys {
+					shell.Printf("    %s=%s\n", k, job.Env[k])
+				}
+			}
+
+			if len(job.Upstream) > 0 {
+				shell.Printf("  Upstream (on %s):\n", job.DependencyCondition)
+				for _, dep := range job.Upstream {
+					shell.Printf("    %s %s [%s]\n", dep.ID[:16], dep.JobName, payload.ParseJobStatus(dep.Status))
+				}
+			}
+			if len(job.Downstream) > 0 {
+				shell.Printf("  Downstream:\n")
+				for _, dep := range job.Downstream {
+					shell.Printf("    %s %s [%s]\n", dep.ID[:16], dep.JobName, payload.ParseJobStatus(dep.Status))
+				}
+			}
+
+			if len(job.Attempts) > 0 {
+				maxAttempts := 1
+				if job.Retry != nil {
+					maxAttempts = job.Retry.MaxAttempts
+				}
+
+				shell.Printf("  Attempts:  %d/%d\n", len(job.Attempts), maxAttempts)
+				for _, attempt := range job.Attempts {
+					if attempt.FinishedAt == nil {
+						shell.Printf("    #%d: running [pid %d, elapsed %s]\n",
+							attempt.Attempt, attempt.PID,
+							lib.HumanizeDuration(now.Sub(attempt.StartedAt)),
+						)
+						continue
+					}
+					shell.Printf("    #%d: exit code %d [pid %d, %s]\n",
+						attempt.Attempt, attempt.ExitCode, attempt.PID,
+						lib.HumanizeDuration(attempt.FinishedAt.Sub(attempt.StartedAt)),
+					)
+				}
+			}
 
 			if showMetadata && job.Metadata != nil {
 				metaBytes, err := json.MarshalIndent(job.Metadata, "", "  ")
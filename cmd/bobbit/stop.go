@@ -13,7 +13,7 @@ func RegisterStopCommand() {
 		Run: func(cmd *cobra.Command, args []string) {
 			jobName := args[0]
 
-			job, err := cli.Stop(jobName)
+			job, err := cli.Stop(cmd.Context(), jobName)
 			if err != nil {
 				shell.Fatalfln(3, "Failed to stop job: %v", err)
 			}
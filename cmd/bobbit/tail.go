@@ -6,8 +6,10 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/mplus-oss/bobbit.go/internal/shell"
+	"github.com/mplus-oss/bobbit.go/payload"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +25,19 @@ func RegisterTailCommand() {
 				shell.Fatalfln(3, "%v", err)
 			}
 
+			stdoutOnly, err := cmd.Flags().GetBool("stdout-only")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			stderrOnly, err := cmd.Flags().GetBool("stderr-only")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			timestamps, err := cmd.Flags().GetBool("timestamps")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
@@ -33,8 +48,16 @@ func RegisterTailCommand() {
 				cancel()
 			}()
 
-			err = cli.TailJobLogWithContext(ctx, args[0], follow, func(line string) error {
-				fmt.Println(line)
+			err = cli.TailJobLogLines(ctx, args[0], follow, func(line payload.LogLine) error {
+				// Lines of older logs have no stream, so they are never filtered out
+				if stdoutOnly && line.Stream != "" && line.Stream != payload.LOG_STREAM_STDOUT {
+					return nil
+				}
+				if stderrOnly && line.Stream != "" && line.Stream != payload.LOG_STREAM_STDERR {
+					return nil
+				}
+
+				printLogLine(line, timestamps)
 				return nil
 			})
 
@@ -44,6 +67,25 @@ func RegisterTailCommand() {
 		},
 	}
 
-	tail.Flags().BoolP("follow", "f", false, "Follow log output (stream mode)")
+	tail.Flags().BoolP("follow", "f", false, "Follow log output until the job is finished (stream mode)")
+	tail.Flags().Bool("stdout-only", false, "Only show the standard output of the job")
+	tail.Flags().Bool("stderr-only", false, "Only show the standard error of the job")
+	tail.Flags().BoolP("timestamps", "t", false, "Show the time each line was written")
+	tail.MarkFlagsMutuallyExclusive("stdout-only", "stderr-only")
 	cmd.AddCommand(tail)
 }
+
+// printLogLine prints a line of the job log. Lines of the standard error go to stderr,
+// every other line goes to stdout.
+func printLogLine(line payload.LogLine, timestamps bool) {
+	text := line.Line
+	if timestamps && line.Timestamp != nil {
+		text = line.Timestamp.Local().Format(time.RFC3339Nano) + " " + text
+	}
+
+	out := os.Stdout
+	if line.Stream == payload.LOG_STREAM_STDERR {
+		out = os.Stderr
+	}
+	fmt.Fprintln(out, text)
+}
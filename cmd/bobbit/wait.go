@@ -1,25 +1,124 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/mplus-oss/bobbit.go/internal/shell"
 	"github.com/mplus-oss/bobbit.go/payload"
 	"github.com/spf13/cobra"
 )
 
 func RegisterWaitCommand() {
-	cmd.AddCommand(&cobra.Command{
-		Use:   "wait <jobID|jobName>",
+	wait := &cobra.Command{
+		Use:   "wait [jobID|jobName]...",
 		Short: "Wait for job.",
-		Long:  "Wait for job. If user provide jobName that have same name, it will using the latest job.",
-		Args:  cobra.ExactArgs(1),
+		Long: "Wait for one or multiple jobs. If user provide jobName that have same name, it will using the latest job.\n\n" +
+			"Exit code is 0 if every finished job succeeded, 1 if any of them did not succeed, and 124 if --timeout has passed.",
+		Example: `  bobbit wait build
+  bobbit wait --any test-unit test-e2e --timeout 30m
+  bobbit wait -m pipeline=42`,
 		Run: func(cmd *cobra.Command, args []string) {
-			job, err := cli.Wait(args[0])
+			metadataFilter, err := cmd.Flags().GetStringToString("metadata")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			if len(args) == 0 && len(metadataFilter) == 0 {
+				shell.Fatalfln(8, "Provide the jobs to wait for, or a metadata filter")
+			}
+			anyMode, err := cmd.Flags().GetBool("any")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			allMode, err := cmd.Flags().GetBool("all")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			timeout, err := cmd.Flags().GetDuration("timeout")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+			toJson, err := cmd.Flags().GetBool("to-json")
+			if err != nil {
+				shell.Fatalfln(3, "%v", err)
+			}
+
+			req := payload.WaitRequestMetadata{
+				Targets:        args,
+				MetadataFilter: metadataFilter,
+			}
+			switch {
+			case anyMode:
+				req.Mode = payload.WAIT_ANY
+			case allMode:
+				req.Mode = payload.WAIT_ALL
+			}
+			if timeout > 0 {
+				deadline := time.Now().Add(timeout)
+				req.Deadline = &deadline
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigChan
+				cancel()
+			}()
+
+			resp, err := cli.WaitJobs(ctx, req)
+			if err == context.Canceled {
+				shell.Fatalfln(130, "Interrupted.")
+			}
 			if err != nil {
 				shell.Fatalfln(3, "Failed to wait for job: %v", err)
 			}
 
-			status := payload.ParseJobStatus(job.Status)
-			shell.Printfln("Job %s is finished with status \"%s\".", args[0], status)
+			exitCode := 0
+			for _, job := range resp.Jobs {
+				if job.Status.IsFinished() && job.Status != payload.JOB_FINISH {
+					exitCode = 1
+				}
+			}
+			if resp.TimedOut {
+				exitCode = 124
+			}
+
+			if toJson {
+				byteStr, err := json.Marshal(resp)
+				if err != nil {
+					shell.Fatalln(3, err.Error())
+				}
+				shell.Println(string(byteStr))
+				os.Exit(exitCode)
+			}
+
+			for _, job := range resp.Jobs {
+				status := payload.ParseJobStatus(job.Status)
+				if job.Status.IsFinished() {
+					shell.Printfln("Job %s [%s] is finished with status \"%s\".", job.JobName, job.ID, status)
+				} else {
+					shell.Printfln("Job %s [%s] is not finished yet, status \"%s\".", job.JobName, job.ID, status)
+				}
+			}
+			if resp.TimedOut {
+				shell.Printfln("Timed out after %v.", timeout)
+			}
+			os.Exit(exitCode)
 		},
-	})
+	}
+	wait.Flags().Bool("any", false, "Return once any of the jobs is finished")
+	wait.Flags().Bool("all", false, "Return once all of the jobs are finished (default)")
+	wait.Flags().StringToStringP("metadata", "m", nil, "Wait for every job with this metadata (e.g., -m 'key1=value1,key2=%%value2%%')")
+	wait.Flags().Duration("timeout", 0, "Stop waiting after this duration and exit with 124 (e.g., 30m)")
+	wait.Flags().BoolP("to-json", "j", false, "Print the waited jobs to stringify JSON")
+	wait.MarkFlagsMutuallyExclusive("any", "all")
+
+	cmd.AddCommand(wait)
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/daemon"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func RegisterConfigCommand() {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration of bobbitd.",
+	}
+
+	printCmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration and where every value comes from.",
+		Long: "Print the effective configuration as a configuration file. Values are taken from flags, " +
+			"environment variables, the configuration file, then defaults. Exits with 1 if the configuration is invalid.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			loader, err := config.LoadFlags(cmd.Flags(), config.DaemonSettings())
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			_, invalid := loader.Daemon()
+			if err := loader.WriteTOML(os.Stdout, config.DaemonSettings()); err != nil {
+				log.Fatalln(err)
+			}
+			if invalid != nil {
+				log.Fatalf("Invalid configuration:\n%v", invalid)
+			}
+		},
+	}
+
+	configCmd.AddCommand(printCmd)
+	cmd.AddCommand(configCmd)
+}
+
+// reloadConfig reads the configuration again on every signal and applies it, see
+// daemon.DaemonStruct.Reload. An invalid configuration is reported and ignored, so the daemon
+// keeps running with the current one. Flags keep precedence over the configuration file.
+func reloadConfig(d *daemon.DaemonStruct, sigChan <-chan os.Signal, flags *pflag.FlagSet) {
+	for sig := range sigChan {
+		log.Printf("Received signal %v. Reloading configuration...", sig)
+
+		loader, err := config.LoadFlags(flags, config.DaemonSettings())
+		if err != nil {
+			log.Printf("[WARNING] Failed to reload configuration, keeping the current one: %v", err)
+			continue
+		}
+		c, err := loader.Daemon()
+		if err != nil {
+			log.Printf("[WARNING] Invalid configuration, keeping the current one:\n%v", err)
+			continue
+		}
+		if err := d.Reload(c); err != nil {
+			log.Printf("[WARNING] Failed to reload configuration, keeping the current one: %v", err)
+		}
+	}
+}
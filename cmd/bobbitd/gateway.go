@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/daemon"
+	"github.com/mplus-oss/bobbit.go/internal/metrics"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// gateway exposes the daemon as a HTTP/JSON REST API. Every HTTP request is forwarded as a
+// request of the socket protocol through an in-memory connection, so the gateway shares the
+// validation, authorization and error codes of the daemon handlers.
+type gateway struct {
+	d *daemon.DaemonStruct
+}
+
+// connContextKey holds the net.Conn of the HTTP request, to identify the client.
+type connContextKey struct{}
+
+// gatewayStatus maps the error codes of the daemon to a HTTP status code.
+var gatewayStatus = map[payload.ErrorCodeEnum]int{
+	payload.ERROR_INVALID_REQUEST:    http.StatusBadRequest,
+	payload.ERROR_UNKNOWN_REQUEST:    http.StatusNotImplemented,
+	payload.ERROR_JOB_NOT_FOUND:      http.StatusNotFound,
+	payload.ERROR_SCHEDULE_NOT_FOUND: http.StatusNotFound,
+	payload.ERROR_LOG_NOT_FOUND:      http.StatusNotFound,
+	payload.ERROR_FORBIDDEN:          http.StatusForbidden,
+	payload.ERROR_CONFLICT:           http.StatusConflict,
+	payload.ERROR_SUBSCRIBER_DROPPED: http.StatusServiceUnavailable,
+	payload.ERROR_INTERNAL:           http.StatusInternalServerError,
+}
+
+// serveGateway serves the HTTP gateway on the listener until it is closed.
+func serveGateway(d *daemon.DaemonStruct, listener net.Listener) {
+	g := &gateway{d: d}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", g.createJob)
+	mux.HandleFunc("GET /jobs", g.listJobs)
+	mux.HandleFunc("GET /jobs/{id}", g.jobStatus)
+	mux.HandleFunc("POST /jobs/{id}/stop", g.stopJob)
+	mux.HandleFunc("GET /jobs/{id}/wait", g.waitJob)
+	mux.HandleFunc("GET /jobs/{id}/logs", g.jobLogs)
+	mux.HandleFunc("GET /metrics", g.metrics)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, connContextKey{}, conn)
+		},
+	}
+	if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Printf("HTTP gateway stopped: %v", err)
+	}
+}
+
+// gatewayStream is a request forwarded to the daemon handlers and the stream of its responses.
+type gatewayStream struct {
+	conn    net.Conn
+	decoder *json.Decoder
+	// stop releases the context of the HTTP request
+	stop func() bool
+}
+
+// forward sends the request to the daemon handlers as the client of the HTTP request.
+// The handlers are interrupted once the HTTP client is gone. The stream must be closed by the caller.
+func (g *gateway) forward(r *http.Request, request payload.PayloadRequestEnum, metadata any) (*gatewayStream, error) {
+	p := payload.JobPayload{Request: request, Timestamp: time.Now()}
+	if err := p.MarshalMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	peer := g.identify(r)
+
+	conn, daemonConn := net.Pipe()
+	go serveJobContext(g.d, g.d.NewJobContextAs(daemonConn, peer))
+
+	s := &gatewayStream{
+		conn:    conn,
+		decoder: json.NewDecoder(conn),
+		stop:    context.AfterFunc(r.Context(), func() { conn.Close() }),
+	}
+	if err := json.NewEncoder(conn).Encode(p); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// next decodes the next response of the daemon. An error response is returned as
+// JobErrorResponse, so it can be forwarded with its code.
+func (s *gatewayStream) next() (json.RawMessage, *payload.JobErrorResponse, error) {
+	var raw json.RawMessage
+	if err := s.decoder.Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+
+	var errorPayload payload.JobErrorResponse
+	if err := json.Unmarshal(raw, &errorPayload); err == nil && errorPayload.Error != "" {
+		return nil, &errorPayload, nil
+	}
+	return raw, nil, nil
+}
+
+// Close closes the connection to the daemon handlers.
+func (s *gatewayStream) Close() {
+	s.stop()
+	s.conn.Close()
+}
+
+// roundTrip forwards a request with a single response, and writes it as the HTTP response with the status.
+func (g *gateway) roundTrip(w http.ResponseWriter, r *http.Request, request payload.PayloadRequestEnum, metadata any, status int) {
+	s, err := g.forward(r, request, metadata)
+	if err != nil {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Failed to forward request", Code: payload.ERROR_INTERNAL, Details: err.Error()})
+		return
+	}
+	defer s.Close()
+
+	raw, errResp, err := s.next()
+	switch {
+	case err != nil:
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Failed to receive response", Code: payload.ERROR_INTERNAL, Details: err.Error()})
+	case errResp != nil:
+		writeGatewayError(w, *errResp)
+	default:
+		writeGatewayJSON(w, status, raw)
+	}
+}
+
+// createJob handles `POST /jobs`. The body is payload.JobDetailMetadata.
+func (g *gateway) createJob(w http.ResponseWriter, r *http.Request) {
+	var p payload.JobDetailMetadata
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&p); err != nil {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Invalid body", Code: payload.ERROR_INVALID_REQUEST, Details: err.Error()})
+		return
+	}
+	g.roundTrip(w, r, payload.REQUEST_EXECUTE_JOB, p, http.StatusCreated)
+}
+
+// listJobs handles `GET /jobs`. The query parameters map to payload.JobSearchMetadata:
+// search, active, finished, queued, limit, page, desc, count, hide_command, and metadata
+// as repeated `key=value` filters.
+func (g *gateway) listJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := payload.JobSearchMetadata{Search: query.Get("search")}
+
+	var err error
+	for name, target := range map[string]*bool{
+		"active":       &req.ActiveOnly,
+		"finished":     &req.FinishOnly,
+		"queued":       &req.QueuedOnly,
+		"desc":         &req.OrderDesc,
+		"count":        &req.NumberOnly,
+		"hide_command": &req.HideCommand,
+	} {
+		if value := query.Get(name); value != "" && err == nil {
+			if *target, err = strconv.ParseBool(value); err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	for name, target := range map[string]*int{"limit": &req.Limit, "page": &req.Page} {
+		if value := query.Get(name); value != "" && err == nil {
+			if *target, err = strconv.Atoi(value); err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	for _, filter := range query["metadata"] {
+		key, value, found := strings.Cut(filter, "=")
+		if !found && err == nil {
+			err = fmt.Errorf("metadata: expected key=value, got %q", filter)
+		}
+		if req.MetadataFilter == nil {
+			req.MetadataFilter = map[string]string{}
+		}
+		req.MetadataFilter[key] = value
+	}
+	if err != nil {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Invalid query", Code: payload.ERROR_INVALID_REQUEST, Details: err.Error()})
+		return
+	}
+
+	g.roundTrip(w, r, payload.REQUEST_LIST, req, http.StatusOK)
+}
+
+// jobStatus handles `GET /jobs/{id}`.
+func (g *gateway) jobStatus(w http.ResponseWriter, r *http.Request) {
+	g.roundTrip(w, r, payload.REQUEST_STATUS, payload.JobSearchMetadata{Search: r.PathValue("id")}, http.StatusOK)
+}
+
+// stopJob handles `POST /jobs/{id}/stop`.
+func (g *gateway) stopJob(w http.ResponseWriter, r *http.Request) {
+	g.roundTrip(w, r, payload.REQUEST_STOP, payload.JobSearchMetadata{Search: r.PathValue("id")}, http.StatusOK)
+}
+
+// waitJob handles `GET /jobs/{id}/wait`, responding with the job once it is finished.
+// The optional `timeout` query parameter, e.g. `30s`, responds with 408 if the job is still running.
+func (g *gateway) waitJob(w http.ResponseWriter, r *http.Request) {
+	req := payload.WaitRequestMetadata{Targets: []string{r.PathValue("id")}}
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			writeGatewayError(w, payload.JobErrorResponse{Error: "Invalid query", Code: payload.ERROR_INVALID_REQUEST, Details: fmt.Sprintf("timeout: %q", value)})
+			return
+		}
+		deadline := time.Now().Add(timeout)
+		req.Deadline = &deadline
+	}
+
+	s, err := g.forward(r, payload.REQUEST_WAIT, req)
+	if err != nil {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Failed to forward request", Code: payload.ERROR_INTERNAL, Details: err.Error()})
+		return
+	}
+	defer s.Close()
+
+	raw, errResp, err := s.next()
+	if err != nil {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Failed to receive response", Code: payload.ERROR_INTERNAL, Details: err.Error()})
+		return
+	}
+	if errResp != nil {
+		writeGatewayError(w, *errResp)
+		return
+	}
+
+	var resp payload.WaitResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Failed to decode response", Code: payload.ERROR_INTERNAL, Details: err.Error()})
+		return
+	}
+	if resp.TimedOut || len(resp.Jobs) == 0 {
+		writeGatewayJSON(w, http.StatusRequestTimeout, payload.JobErrorResponse{Error: "Timed out while waiting for the job", JobID: r.PathValue("id")})
+		return
+	}
+	writeGatewayJSON(w, http.StatusOK, resp.Jobs[0])
+}
+
+// jobLogs handles `GET /jobs/{id}/logs`. The lines are streamed as Server-Sent Events of
+// payload.LogLine if the client accepts `text/event-stream`, or else as chunked plain text.
+// With `follow=1`, the stream ends once the job is finished.
+func (g *gateway) jobLogs(w http.ResponseWriter, r *http.Request) {
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+
+	s, err := g.forward(r, payload.REQUEST_TAIL_LOG, payload.JobSearchMetadata{Search: r.PathValue("id"), Follow: follow})
+	if err != nil {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Failed to forward request", Code: payload.ERROR_INTERNAL, Details: err.Error()})
+		return
+	}
+	defer s.Close()
+
+	// Errors before the first line, e.g. an unknown job, are still sent with their status
+	raw, errResp, err := s.next()
+	if errResp != nil {
+		writeGatewayError(w, *errResp)
+		return
+	}
+	if err != nil && err != io.EOF {
+		writeGatewayError(w, payload.JobErrorResponse{Error: "Failed to receive response", Code: payload.ERROR_INTERNAL, Details: err.Error()})
+		return
+	}
+
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	for err == nil {
+		switch {
+		case errResp != nil && sse:
+			errBytes, _ := json.Marshal(errResp)
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", errBytes)
+		case errResp != nil:
+			// Plain text has no way to report the error, so the stream is just cut
+			log.Printf("HTTP gateway: log stream of %s failed: %s", r.PathValue("id"), errResp.Error)
+		case sse:
+			fmt.Fprintf(w, "data: %s\n\n", raw)
+		default:
+			var line payload.LogLine
+			if err := json.Unmarshal(raw, &line); err == nil {
+				fmt.Fprintln(w, line.Line)
+			}
+		}
+		if errResp != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		raw, errResp, err = s.next()
+	}
+	if sse {
+		fmt.Fprint(w, "event: end\ndata: {}\n\n")
+	}
+}
+
+// identify reads the credentials of the client of the HTTP request, see daemon.DaemonStruct.Identify.
+func (g *gateway) identify(r *http.Request) *daemon.PeerCredentials {
+	if conn, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+		return g.d.Identify(conn)
+	}
+	return nil
+}
+
+// metrics serves the metrics to the clients allowed to list every job, see daemon.DaemonStruct.AuthorizeMetrics.
+func (g *gateway) metrics(w http.ResponseWriter, r *http.Request) {
+	if err := g.d.AuthorizeMetrics(g.identify(r)); err != nil {
+		writeGatewayError(w, daemon.ErrorResponse(err))
+		return
+	}
+	metrics.Default.Handler().ServeHTTP(w, r)
+}
+
+// writeGatewayError writes the error response with the HTTP status of its code.
+func writeGatewayError(w http.ResponseWriter, errResp payload.JobErrorResponse) {
+	status, ok := gatewayStatus[errResp.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeGatewayJSON(w, status, errResp)
+}
+
+// writeGatewayJSON writes the value as JSON response with the status.
+func writeGatewayJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("HTTP gateway: failed to write response: %v", err)
+	}
+}
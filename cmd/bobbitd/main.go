@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net"
 	"os"
@@ -9,39 +10,91 @@ import (
 
 	"github.com/mplus-oss/bobbit.go/config"
 	"github.com/mplus-oss/bobbit.go/daemon"
+	"github.com/mplus-oss/bobbit.go/payload"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	c       = config.NewDaemon()
 	sigChan = make(chan os.Signal, 1)
-	cmd     = &cobra.Command{
-		Use:   "bobbitd",
-		Short: "Daemon worker for bobbit.",
+	// reloadChan receives SIGHUP, which reloads the configuration
+	reloadChan = make(chan os.Signal, 1)
+	cmd        = &cobra.Command{
+		Use:     "bobbitd",
+		Short:   "Daemon worker for bobbit.",
+		Version: config.Version,
 	}
 )
 
 func init() {
+	config.RegisterFlags(cmd.PersistentFlags(), config.DaemonSettings())
+	RegisterConfigCommand()
+
 	cmd.Run = func(cmd *cobra.Command, args []string) {
+		loader, err := config.LoadFlags(cmd.Flags(), config.DaemonSettings())
+		if err != nil {
+			log.Fatalln(err)
+		}
+		c, err := loader.Daemon()
+		if err != nil {
+			log.Fatalf("Invalid configuration:\n%v", err)
+		}
+
+		log.Printf("Version: %s (protocol %d)", config.Version, payload.ProtocolVersion)
+		if loader.Path != "" {
+			log.Printf("Configuration file: %s", loader.Path)
+		}
 		log.Printf("Directory data: %s", c.DataPath)
 		log.Printf("Socket Path: %s", c.SocketPath)
+		if c.ListenTCP != "" {
+			log.Printf("TCP Address: %s", c.ListenTCP)
+		}
+		if c.HTTPListen != "" {
+			log.Printf("HTTP Gateway: %s", c.HTTPListen)
+		}
+		if c.MetricsListen != "" {
+			log.Printf("Metrics: %s", c.MetricsListen)
+		}
 
-		startDaemon()
+		startDaemon(c, cmd.Flags())
 	}
 }
 
-func startDaemon() {
+func startDaemon(c config.BobbitDaemonConfig, flags *pflag.FlagSet) {
 	d, err := daemon.CreateDaemon(c)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(reloadChan, syscall.SIGHUP)
 	go d.CleanupDaemon(sigChan)
+	go reloadConfig(d, reloadChan, flags)
+	go d.RunDispatcher()
+	go d.RunScheduler()
+	go d.RunJanitor()
 	log.Println("Daemon started, waiting for response.")
 
+	if d.TCPListener != nil {
+		go acceptConnections(d, d.TCPListener)
+	}
+	if d.HTTPListener != nil {
+		go serveGateway(d, d.HTTPListener)
+	}
+	if d.MetricsListener != nil {
+		go serveMetrics(d, d.MetricsListener)
+	}
+	acceptConnections(d, d.SocketListener)
+}
+
+// acceptConnections handles the connections of the listener until it is closed.
+// Both the socket and the TCP listener speak the same protocol.
+func acceptConnections(d *daemon.DaemonStruct, listener net.Listener) {
 	for {
-		conn, err := d.SocketListener.Accept()
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
 			log.Printf("Failed to receive connection: %v", err)
 			continue
@@ -54,9 +107,16 @@ func startDaemon() {
 func handleConnection(d *daemon.DaemonStruct, conn net.Conn) {
 	defer conn.Close()
 
-	jobCtx := d.NewJobContext(conn)
+	serveJobContext(d, d.NewJobContext(conn))
+}
+
+// serveJobContext reads the request of the client and routes it to its handler.
+func serveJobContext(d *daemon.DaemonStruct, jobCtx *daemon.JobContext) {
 	defer jobCtx.Close()
 
+	openConnections.Add(1)
+	defer openConnections.Add(-1)
+
 	if err := jobCtx.GetPayload(); err != nil {
 		log.Println(err)
 		return
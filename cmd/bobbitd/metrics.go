@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/daemon"
+	"github.com/mplus-oss/bobbit.go/internal/metrics"
+)
+
+var (
+	requestDuration = metrics.Default.NewHistogramVec(
+		"bobbitd_request_duration_seconds",
+		"Time to handle a request, by request type. Streaming requests last until the stream ends.",
+		metrics.DefBuckets,
+		"request",
+	)
+	openConnections = metrics.Default.NewGauge(
+		"bobbitd_connections_open",
+		"Number of open client connections, including the requests forwarded by the HTTP gateway.",
+	)
+)
+
+// serveMetrics serves the metrics as `GET /metrics` on the listener until it is closed.
+// The clients are authorized like on the HTTP gateway, see gateway.metrics.
+func serveMetrics(d *daemon.DaemonStruct, listener net.Listener) {
+	g := &gateway{d: d}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", g.metrics)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, connContextKey{}, conn)
+		},
+	}
+	if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Printf("Metrics listener stopped: %v", err)
+	}
+}
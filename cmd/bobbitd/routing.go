@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/mplus-oss/bobbit.go/daemon"
@@ -18,15 +19,33 @@ import (
 // See: const main.RunJob:ignoredRoutes
 type RouteHandlerMap map[payload.PayloadRequestEnum]func(*daemon.JobContext) error
 
+// Requests returns the request types of the map in ascending order.
+func (r RouteHandlerMap) Requests() []payload.PayloadRequestEnum {
+	requests := make([]payload.PayloadRequestEnum, 0, len(r))
+	for request := range r {
+		requests = append(requests, request)
+	}
+	slices.Sort(requests)
+	return requests
+}
+
 func RouteHandler(d *daemon.DaemonStruct, jc *daemon.JobContext) {
 	handlers := RouteHandlerMap{
-		payload.REQUEST_VIBE_CHECK:  d.HandleVibeCheck,
-		payload.REQUEST_LIST:        d.ListJob,
-		payload.REQUEST_EXECUTE_JOB: d.HandleJob,
-		payload.REQUEST_WAIT:        d.WaitJob,
-		payload.REQUEST_STATUS:      d.StatusJob,
-		payload.REQUEST_STOP:        d.StopJob,
-		payload.REQUEST_TAIL_LOG:    d.HandleTailJobLog,
+		payload.REQUEST_VIBE_CHECK:      d.HandleVibeCheck,
+		payload.REQUEST_LIST:            d.ListJob,
+		payload.REQUEST_EXECUTE_JOB:     d.HandleJob,
+		payload.REQUEST_WAIT:            d.WaitJob,
+		payload.REQUEST_STATUS:          d.StatusJob,
+		payload.REQUEST_STOP:            d.StopJob,
+		payload.REQUEST_TAIL_LOG:        d.HandleTailJobLog,
+		payload.REQUEST_SCHEDULE:        d.HandleSchedule,
+		payload.REQUEST_DOCTOR:          d.Doctor,
+		payload.REQUEST_PRUNE:           d.Prune,
+		payload.REQUEST_WATCH:           d.WatchJobs,
+		payload.REQUEST_UPDATE_METADATA: d.UpdateJobMetadata,
+	}
+	handlers[payload.REQUEST_INFO] = func(jc *daemon.JobContext) error {
+		return d.Info(jc, handlers.Requests())
 	}
 
 	var (
@@ -39,12 +58,16 @@ func RouteHandler(d *daemon.DaemonStruct, jc *daemon.JobContext) {
 		hName = payload.ParsePayloadRequest(jc.Payload.Request)
 		err = RunJob(d, jc, hName, h)
 	} else {
-		err = fmt.Errorf("Outbound request: %v", jc.Payload.Request)
+		err = &daemon.DaemonError{
+			Message:     "Outbound request",
+			ParentError: fmt.Errorf("request: %v", jc.Payload.Request),
+			Code:        payload.ERROR_UNKNOWN_REQUEST,
+		}
 	}
 
 	if err != nil {
 		log.Printf("Error processing %v: %v", hName, err)
-		if sendErr := jc.SendPayload(payload.JobErrorResponse{Error: err.Error()}); sendErr != nil {
+		if sendErr := jc.SendPayload(daemon.ErrorResponse(err)); sendErr != nil {
 			log.Println("Failed to send error response:", sendErr)
 		}
 	}
@@ -54,8 +77,9 @@ func RunJob(d *daemon.DaemonStruct, jc *daemon.JobContext, name string, handler
 	// Ignore this route from log if the app is not on DebugMode
 	const ignoredRoutes = payload.REQUEST_LIST |
 		payload.REQUEST_VIBE_CHECK |
-		payload.REQUEST_STATUS
-	shouldLog := d.DebugMode || !((ignoredRoutes & jc.Payload.Request) > 0)
+		payload.REQUEST_STATUS |
+		payload.REQUEST_INFO
+	shouldLog := d.Config().DebugMode || !((ignoredRoutes & jc.Payload.Request) > 0)
 
 	// Add hash for logging
 	hash, _ := lib.GenerateRandomHash(8)
@@ -67,8 +91,10 @@ func RunJob(d *daemon.DaemonStruct, jc *daemon.JobContext, name string, handler
 	start := time.Now()
 	err := handler(jc)
 
+	duration := time.Since(start)
+	requestDuration.Observe(duration.Seconds(), name)
+
 	if shouldLog {
-		duration := time.Since(start)
 		if err != nil {
 			log.Printf("[%s] FAILED: %s | Took: %v | Error: %v", hash, name, duration, err)
 		} else {
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/client"
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/daemon"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// testCA signs the certificates of the daemon and the clients of a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	// path is the PEM file of cert
+	path string
+}
+
+func newTestCA(t *testing.T, dir string, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name+".pem")
+	writePEM(t, path, "CERTIFICATE", der)
+	return &testCA{cert: cert, key: key, path: path}
+}
+
+// issue signs a certificate for the common name and returns the paths of its PEM
+// certificate and key. Server certificates are valid for 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, dir string, commonName string, server bool) (certPath string, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath = filepath.Join(dir, commonName+".pem")
+	keyPath = filepath.Join(dir, commonName+"-key.pem")
+	writePEM(t, certPath, "CERTIFICATE", der)
+	writePEM(t, keyPath, "EC PRIVATE KEY", keyDER)
+	return certPath, keyPath
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTLSDaemon starts a daemon listening on loopback TCP ports for its clients and its
+// metrics, with the authorization policy. The jobs are not dispatched.
+func startTLSDaemon(t *testing.T, dir string, ca *testCA, policy string) *daemon.DaemonStruct {
+	t.Helper()
+	policyPath := filepath.Join(dir, "policy.json")
+	if err := os.WriteFile(policyPath, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := ca.issue(t, dir, "bobbitd", true)
+
+	t.Setenv("BOBBIT_CONFIG", filepath.Join(dir, "bobbit.conf"))
+	if err := os.WriteFile(filepath.Join(dir, "bobbit.conf"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader, err := config.NewLoader("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := loader.Daemon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.DataPath = filepath.Join(dir, "data")
+	c.SocketPath = filepath.Join(dir, "bobbitd.sock")
+	c.ListenTCP = "127.0.0.1:0"
+	c.MetricsListen = "tcp://127.0.0.1:0"
+	c.TLSCertPath = certPath
+	c.TLSKeyPath = keyPath
+	c.TLSClientCAPath = ca.path
+	c.AuthPolicyPath = policyPath
+
+	d, err := daemon.CreateDaemon(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		d.TCPListener.Close()
+		d.MetricsListener.Close()
+		d.SocketListener.Close()
+		d.DB.Close()
+	})
+	go acceptConnections(d, d.TCPListener)
+	go serveMetrics(d, d.MetricsListener)
+
+	return d
+}
+
+// newTLSClient returns a client connecting to the daemon at address with the certificate of commonName.
+func newTLSClient(t *testing.T, dir string, ca *testCA, address string, commonName string) *client.DaemonConnectionStruct {
+	t.Helper()
+	certPath, keyPath := ca.issue(t, dir, commonName, false)
+	return client.New(config.BobbitClientConfig{
+		Address:     "tcp://" + address,
+		TLSCertPath: certPath,
+		TLSKeyPath:  keyPath,
+		TLSCAPath:   ca.path,
+		DialTimeout: 5 * time.Second,
+	})
+}
+
+const testPolicy = `{
+  "default": {"create": false, "stop": "none", "logs": "none", "list": "none"},
+  "users": {"ops": {"create": true, "list": "all"}}
+}`
+
+func TestTCPListener(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir, "ca")
+	d := startTLSDaemon(t, dir, ca, testPolicy)
+	address := d.TCPListener.Addr().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("handshake", func(t *testing.T) {
+		cli := newTLSClient(t, dir, ca, address, "ops")
+		info, err := cli.Info(ctx)
+		if err != nil {
+			t.Fatalf("Info over mutual TLS: %v", err)
+		}
+		if info.ProtocolVersion != payload.ProtocolVersion {
+			t.Errorf("protocol version = %d, want %d", info.ProtocolVersion, payload.ProtocolVersion)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		cli := newTLSClient(t, dir, ca, address, "ops")
+		created, err := cli.Create(ctx, payload.JobDetailMetadata{JobName: "remote", Command: []string{"true"}})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if created.Owner != nil {
+			t.Errorf("job of a remote client has owner %+v, want none", *created.Owner)
+		}
+
+		job, err := cli.Status(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("Status: %v", err)
+		}
+		if job.ID != created.ID || job.JobName != "remote" {
+			t.Errorf("Status = %s %q, want %s %q", job.ID, job.JobName, created.ID, "remote")
+		}
+	})
+
+	t.Run("authorization by common name", func(t *testing.T) {
+		cli := newTLSClient(t, dir, ca, address, "guest")
+		if _, err := cli.Create(ctx, payload.JobDetailMetadata{JobName: "denied", Command: []string{"true"}}); !errors.Is(err, client.ErrForbidden) {
+			t.Errorf("Create as guest: got %v, want %v", err, client.ErrForbidden)
+		}
+		if _, err := cli.List(ctx, payload.JobSearchMetadata{}); !errors.Is(err, client.ErrForbidden) {
+			t.Errorf("List as guest: got %v, want %v", err, client.ErrForbidden)
+		}
+	})
+
+	t.Run("client without certificate", func(t *testing.T) {
+		expectRejected(t, address, ca, nil)
+	})
+
+	t.Run("client certificate of another CA", func(t *testing.T) {
+		other := newTestCA(t, t.TempDir(), "other-ca")
+		certPath, keyPath := other.issue(t, t.TempDir(), "ops", false)
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectRejected(t, address, ca, &cert)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		metricsURL := "https://" + d.MetricsListener.Addr().String() + "/metrics"
+		for _, tc := range []struct {
+			commonName string
+			status     int
+		}{
+			{"ops", http.StatusOK},
+			{"guest", http.StatusForbidden},
+		} {
+			certPath, keyPath := ca.issue(t, dir, tc.commonName, false)
+			tlsConfig, err := config.LoadTLSConfig(certPath, keyPath, ca.path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}, Timeout: 5 * time.Second}
+			resp, err := httpClient.Get(metricsURL)
+			if err != nil {
+				t.Fatalf("GET /metrics as %s: %v", tc.commonName, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("GET /metrics as %s: status %d, want %d", tc.commonName, resp.StatusCode, tc.status)
+			}
+		}
+	})
+}
+
+// expectRejected sends a request with the certificate, if any, and checks the daemon does not
+// answer it. With TLS 1.3 the client completes its side of the handshake before the daemon
+// verifies the certificate, so the rejection is only seen when reading.
+func expectRejected(t *testing.T, address string, ca *testCA, cert *tls.Certificate) {
+	t.Helper()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	tlsConfig := &tls.Config{RootCAs: pool}
+	if cert != nil {
+		// Present the certificate even though the daemon does not accept its CA
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+	}
+	conn, err := tls.Dial("tcp", address, tlsConfig)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	json.NewEncoder(conn).Encode(payload.JobPayload{Request: payload.REQUEST_INFO, Timestamp: time.Now()})
+	var info payload.InfoResponse
+	err = json.NewDecoder(conn).Decode(&info)
+	if err == nil {
+		t.Fatalf("daemon answered %+v, want the connection rejected", info)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatalf("daemon neither answered nor rejected the connection: %v", err)
+	}
+	// The daemon rejects the certificate with a TLS alert, instead of closing the connection
+	if !strings.Contains(err.Error(), "remote error: tls:") {
+		t.Errorf("got %v, want a TLS alert of the daemon", err)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// AuthScopeEnum defines the jobs a user may act on.
+type AuthScopeEnum string
+
+const (
+	// AUTH_SCOPE_NONE denies the action on every job.
+	AUTH_SCOPE_NONE AuthScopeEnum = "none"
+	// AUTH_SCOPE_OWN allows the action on the jobs submitted by the user.
+	AUTH_SCOPE_OWN AuthScopeEnum = "own"
+	// AUTH_SCOPE_ALL allows the action on every job.
+	AUTH_SCOPE_ALL AuthScopeEnum = "all"
+)
+
+// AuthRule defines what a user may do. Unset fields fall back to the default rule of the policy.
+type AuthRule struct {
+	// Create allows to submit jobs and to create or trigger schedules.
+	Create *bool `json:"create,omitempty"`
+	// Stop defines the jobs the user may stop or update the metadata of. It also applies to
+	// removing, pausing and resuming schedules, and to pruning, which needs AUTH_SCOPE_ALL.
+	Stop AuthScopeEnum `json:"stop,omitempty"`
+	// Logs defines the jobs the user may read the log of.
+	Logs AuthScopeEnum `json:"logs,omitempty"`
+	// List defines the jobs the user may list, wait for, watch or get the status of.
+	// The doctor report needs AUTH_SCOPE_ALL.
+	List AuthScopeEnum `json:"list,omitempty"`
+}
+
+// AuthPolicy controls what the users connecting to the socket may do.
+// The rule of a user is taken from Users, or else merged from the most permissive
+// rules of its Groups, or else Default. The root user and the daemon user may do anything.
+//
+// Example:
+//
+//	{
+//	  "default": {"create": true, "stop": "own", "logs": "own", "list": "own"},
+//	  "groups": {"ops": {"stop": "all", "logs": "all", "list": "all"}},
+//	  "users": {"guest": {"create": false, "list": "all"}}
+//	}
+type AuthPolicy struct {
+	// Default applies to users without rule. Unset fields allow to create jobs
+	// and to act on the jobs of the user.
+	Default AuthRule `json:"default"`
+	// Users holds the rules per user name or user ID.
+	Users map[string]AuthRule `json:"users,omitempty"`
+	// Groups holds the rules per group name or group ID.
+	Groups map[string]AuthRule `json:"groups,omitempty"`
+}
+
+// LoadAuthPolicy reads and validates the authorization policy from a JSON file.
+func LoadAuthPolicy(path string) (*AuthPolicy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy AuthPolicy
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return nil, fmt.Errorf("invalid auth policy %s: %w", path, err)
+	}
+
+	rules := map[string]AuthRule{"default": policy.Default}
+	for name, rule := range policy.Users {
+		rules["user "+name] = rule
+	}
+	for name, rule := range policy.Groups {
+		rules["group "+name] = rule
+	}
+	for name, rule := range rules {
+		for _, scope := range []AuthScopeEnum{rule.Stop, rule.Logs, rule.List} {
+			switch scope {
+			case "", AUTH_SCOPE_NONE, AUTH_SCOPE_OWN, AUTH_SCOPE_ALL:
+			default:
+				return nil, fmt.Errorf("invalid auth policy %s: unknown scope %q in %s", path, scope, name)
+			}
+		}
+	}
+
+	return &policy, nil
+}
@@ -1,13 +1,43 @@
 package config
 
+import "time"
+
 type BobbitClientConfig struct {
+	// Address is the address of the daemon, either `unix:///path/to/socket` or `tcp://host:port`.
+	// The default is empty, which connects to SocketPath.
+	Address string
+	// TLSCertPath is the PEM client certificate presented to a daemon over TCP.
+	TLSCertPath string
+	// TLSKeyPath is the PEM private key of TLSCertPath.
+	TLSKeyPath string
+	// TLSCAPath is the PEM CA that verifies the certificate of a daemon over TCP.
+	TLSCAPath string
+	// TLSServerName overrides the name verified in the certificate of the daemon.
+	// The default is the host of Address.
+	TLSServerName string
+	// DialTimeout limits the time to connect to the daemon, for each attempt. The default is 5s.
+	DialTimeout time.Duration
+	// DialRetries is the number of extra attempts to connect when the daemon is unavailable,
+	// e.g. while it is restarting. The default is 2.
+	DialRetries int
+	// DialRetryDelay is the time between two attempts to connect. The default is 200ms.
+	DialRetryDelay time.Duration
 	// BobbitConfig holds the configuration parameters for the Bobbit daemon.
 	BobbitConfig
 }
 
 // NewClient creates and initializes a new BobbitConfig instance for Bobbit client.
+// It only reads environment variables, invalid values fall back to their default. See Loader
+// for the configuration file and flags.
 func NewClient() BobbitClientConfig {
-	return BobbitClientConfig{
-		BobbitConfig: BaseConfig(),
+	c, _ := (&Loader{}).Client()
+	return c
+}
+
+// Endpoint returns the network and the address of the daemon, see Address.
+func (c BobbitClientConfig) Endpoint() (network string, address string, err error) {
+	if c.Address == "" {
+		return "unix", c.SocketPath, nil
 	}
+	return ParseAddress(c.Address)
 }
@@ -5,11 +5,15 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
-	"github.com/mplus-oss/bobbit.go/internal/lib"
 	"github.com/mplus-oss/bobbit.go/payload"
 )
 
+// Version is the version of this build of bobbit and bobbitd.
+// It is set at build time, see build/binary/compile.sh.
+var Version = "dev"
+
 // BobbitConfig holds the configuration parameters for the Bobbit.
 type BobbitConfig struct {
 	// SocketPath specifies the file system path for the Unix domain socket.
@@ -34,27 +38,32 @@ type BobbitConfig struct {
 
 // New creates and initializes a new BobbitConfig instance.
 // It retrieves configuration values from environment variables or uses default paths.
-// DebugMode is enabled if the "DEBUG" environment variable is set to any non-empty value.
+// DebugMode is enabled if the "DEBUG" environment variable is set to any non-empty value
+// but a false one, e.g. `0` or `false`.
 func BaseConfig() BobbitConfig {
-	debug := false
-	if env := os.Getenv("DEBUG"); env != "" {
-		debug = true
-	}
+	return (&Loader{}).base()
+}
 
-	return BobbitConfig{
-		DataPath:   lib.GetDefaultEnv("BOBBIT_DATA_DIR", "/tmp/bobbitd/"),
-		SocketPath: lib.GetDefaultEnv("BOBBIT_SOCKET_PATH", "/tmp/bobbitd.sock"),
-		DebugMode:  debug,
+// ParseAddress splits an address in `unix:///path/to/socket` or `tcp://host:port` format
+// into its network and address, as expected by net.Dial and net.Listen.
+func ParseAddress(s string) (network string, address string, err error) {
+	switch {
+	case strings.HasPrefix(s, "unix://") && len(s) > len("unix://"):
+		return "unix", strings.TrimPrefix(s, "unix://"), nil
+	case strings.HasPrefix(s, "tcp://") && len(s) > len("tcp://"):
+		return "tcp", strings.TrimPrefix(s, "tcp://"), nil
 	}
+	return "", "", fmt.Errorf("invalid address %q: expected unix:///path or tcp://host:port", s)
 }
 
 // GenerateJobLogPath will generate full path of log path.
 // It automatically creates the parent directories if they do not exist.
 func GenerateJobLogPath(c BobbitConfig, p payload.JobDetailMetadata) string {
+	createdAt := p.CreatedAt.UTC()
 	fullPath := filepath.Join(
 		c.DataPath, "logs",
-		strconv.Itoa(p.CreatedAt.Year()),
-		fmt.Sprintf("%02d", p.CreatedAt.Month()),
+		strconv.Itoa(createdAt.Year()),
+		fmt.Sprintf("%02d", createdAt.Month()),
 		p.ID,
 	)
 
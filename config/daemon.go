@@ -1,9 +1,14 @@
 package config
 
 import (
+	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/mplus-oss/bobbit.go/internal/lib"
+	"github.com/mplus-oss/bobbit.go/internal/joblog"
+	"github.com/mplus-oss/bobbit.go/payload"
 )
 
 type BobbitDaemonConfig struct {
@@ -15,24 +20,93 @@ type BobbitDaemonConfig struct {
 	//
 	// Check `(sql.DB).SetMaxIdleConns` for more information.
 	DBMaxIdleConn int
+	// KillGracePeriod is the default time between SIGTERM and SIGKILL when a job exceeds its
+	// timeout and the job does not provide its own grace period. The default is 10s.
+	KillGracePeriod time.Duration
+	// MaxConcurrentJobs limits the number of jobs running at the same time across every queue.
+	// Excess jobs wait in the queue. The default is 0, which means unlimited.
+	MaxConcurrentJobs int
+	// QueueLimits limits the number of jobs running at the same time per queue.
+	// Key is the queue name. Queues without limit (or with limit 0) are only bound by MaxConcurrentJobs.
+	QueueLimits map[string]int
+	// LogMaxSize is the default maximum size of a job log in bytes, for jobs that do not provide
+	// their own limit. The default is 0, which means unlimited.
+	LogMaxSize int64
+	// LogPolicy defines what happens when a job log reaches its maximum size, for jobs that do
+	// not provide their own policy. The default is rotate.
+	LogPolicy payload.LogPolicyEnum
+	// LogMaxSegments is the number of rotated segments kept per job log. The default is 5.
+	LogMaxSegments int
+	// LogCompression is the compression of job logs once the job is finished. The default is none.
+	LogCompression joblog.CompressionEnum
+	// RetentionMaxAge deletes finished jobs and their logfiles once they were finished longer
+	// than this duration. The default is 0, which keeps jobs forever.
+	RetentionMaxAge time.Duration
+	// RetentionFailedMaxAge overrides RetentionMaxAge for failed, timed out and lost jobs, so they
+	// can be kept longer for inspection. The default is RetentionMaxAge.
+	RetentionFailedMaxAge time.Duration
+	// RetentionMaxPerName keeps only this number of the latest finished jobs per job name.
+	// The default is 0, which means unlimited.
+	RetentionMaxPerName int
+	// RetentionInterval is the time between two runs of the retention janitor. The default is 1h.
+	RetentionInterval time.Duration
+	// AuthPolicyPath is the path of the JSON authorization policy, see AuthPolicy.
+	// The default is empty, which allows every user who can open the socket to do anything.
+	AuthPolicyPath string
+	// SocketMode is the file mode of the socket. The default is 0, which keeps the mode given by the umask.
+	SocketMode os.FileMode
+	// SocketOwner is the owner of the socket in `user:group` format. Both parts are optional,
+	// and can be a name or an ID. The default is empty, which keeps the daemon user as owner.
+	SocketOwner string
+	// ListenTCP is the address of the TCP listener for remote clients, e.g. `:7420`.
+	// Remote clients must present a certificate signed by TLSClientCAPath.
+	// The default is empty, which only listens on the socket.
+	ListenTCP string
+	// TLSCertPath is the PEM certificate of the TCP listener.
+	TLSCertPath string
+	// TLSKeyPath is the PEM private key of TLSCertPath.
+	TLSKeyPath string
+	// TLSClientCAPath is the PEM CA that verifies the certificates of remote clients.
+	TLSClientCAPath string
+	// HTTPListen is the address of the HTTP gateway, either `unix:///path/to/socket` or `tcp://host:port`.
+	// Over TCP, the gateway requires mutual TLS if TLSCertPath is set. The default is empty, which
+	// disables the gateway.
+	HTTPListen string
+	// MetricsListen is the address serving the Prometheus metrics as `/metrics`, either
+	// `unix:///path/to/socket` or `tcp://host:port`. Over TCP, the metrics require mutual TLS
+	// like ListenTCP. The metrics are also served by the HTTP gateway.
+	// The default is empty, which disables the listener.
+	MetricsListen string
 	// BobbitConfig holds the configuration parameters for the Bobbit daemon.
 	BobbitConfig
 }
 
 // NewDaemon creates and initializes a new BobbitConfig instance for Bobbit daemon.
+// It only reads environment variables, invalid values fall back to their default. See Loader
+// for the configuration file and flags.
 func NewDaemon() BobbitDaemonConfig {
-	maxOpenConn, err := strconv.Atoi(lib.GetDefaultEnv("BOBBITD_DB_MAX_OPEN_CONN", "1"))
-	if err != nil {
-		maxOpenConn = 1
-	}
-	maxIdleConn, err := strconv.Atoi(lib.GetDefaultEnv("BOBBITD_DB_MAX_IDLE_CONN", "1"))
-	if err != nil {
-		maxIdleConn = 1
-	}
+	c, _ := (&Loader{}).Daemon()
+	return c
+}
+
+// ParseQueueLimits parses per-queue concurrency limits in `queue=limit,queue=limit` format.
+func ParseQueueLimits(s string) (map[string]int, error) {
+	limits := map[string]int{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
 
-	return BobbitDaemonConfig{
-		DBMaxOpenConn: maxOpenConn,
-		DBMaxIdleConn: maxIdleConn,
-		BobbitConfig:  BaseConfig(),
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid queue limit %q: expected queue=limit", pair)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid queue limit %q: limit must be a non-negative number", pair)
+		}
+		limits[strings.TrimSpace(name)] = limit
 	}
+	return limits, nil
 }
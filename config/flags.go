@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// RegisterFlags adds `--config` and a flag for every setting, e.g. `--max-concurrent-jobs`.
+// Flags take precedence over environment variables and the configuration file.
+func RegisterFlags(flags *pflag.FlagSet, settings []Setting) {
+	flags.String("config", "", fmt.Sprintf("Path of the configuration file (default $BOBBIT_CONFIG or %s)", DefaultConfigPath))
+	for _, s := range settings {
+		flags.String(s.Flag(), s.Default, s.Usage)
+		if s.Kind == SETTING_BOOL {
+			flags.Lookup(s.Flag()).NoOptDefVal = "true"
+		}
+	}
+}
+
+// LoadFlags reads the configuration file given by `--config` and the settings set on the
+// command line, see RegisterFlags.
+func LoadFlags(flags *pflag.FlagSet, settings []Setting) (*Loader, error) {
+	path, err := flags.GetString("config")
+	if err != nil {
+		return nil, err
+	}
+
+	byFlag := map[string]Setting{}
+	for _, s := range settings {
+		byFlag[s.Flag()] = s
+	}
+	values := map[string]string{}
+	flags.Visit(func(f *pflag.Flag) {
+		if s, ok := byFlag[f.Name]; ok {
+			values[s.Env] = f.Value.String()
+		}
+	})
+
+	return NewLoader(path, values)
+}
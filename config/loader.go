@@ -0,0 +1,405 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mplus-oss/bobbit.go/internal/joblog"
+	"github.com/mplus-oss/bobbit.go/internal/lib"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// DefaultConfigPath is the configuration file read when neither `--config` nor `BOBBIT_CONFIG`
+// is set. Unlike an explicit path, it does not need to exist or be readable, e.g. by the users
+// running bobbit when only the daemon may read it.
+const DefaultConfigPath = "/etc/bobbit/bobbitd.toml"
+
+// Loader resolves the value of every setting by precedence: flags, environment variables,
+// configuration file, then defaults. See Setting.
+type Loader struct {
+	// Path is the configuration file that was read. It is empty without configuration file.
+	Path string
+	// flags and file hold the values given on the command line and in the configuration file,
+	// keyed by the environment variable of their setting.
+	flags map[string]string
+	file  map[string]string
+	// errs collects the invalid settings, see Daemon and Client.
+	errs []error
+}
+
+// NewLoader reads the configuration file at path, or at `BOBBIT_CONFIG` if path is empty, or
+// at DefaultConfigPath if it exists. flags holds the values given on the command line, keyed
+// by the environment variable of their setting.
+func NewLoader(path string, flags map[string]string) (*Loader, error) {
+	explicit := true
+	if path == "" {
+		path = os.Getenv("BOBBIT_CONFIG")
+	}
+	if path == "" {
+		path = DefaultConfigPath
+		explicit = false
+	}
+
+	l := &Loader{flags: flags, file: map[string]string{}}
+	values, err := readConfigFile(path)
+	if (errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission)) && !explicit {
+		return l, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("configuration file %s does not exist", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l.Path = path
+	l.file = values
+	return l, nil
+}
+
+// Lookup returns the value of the setting and where it comes from, e.g. `env BOBBITD_LOG_POLICY`.
+// An empty environment variable resets the setting to its default, e.g. `BOBBITD_QUEUE_LIMITS=`
+// removes the queue limits of the configuration file.
+func (l *Loader) Lookup(s Setting) (value string, origin string) {
+	if value, ok := l.flags[s.Env]; ok {
+		return value, "flag --" + s.Flag()
+	}
+	if value, ok := os.LookupEnv(s.Env); ok {
+		if value == "" {
+			value = s.Default
+		}
+		return value, "env " + s.Env
+	}
+	if value, ok := l.file[s.Env]; ok {
+		return value, fmt.Sprintf("file %s (%s)", l.Path, s.Key)
+	}
+	return s.Default, "default"
+}
+
+// Daemon resolves the configuration of bobbitd. Every invalid setting is reported in the
+// returned error, and replaced by its default in the configuration.
+func (l *Loader) Daemon() (BobbitDaemonConfig, error) {
+	l.errs = nil
+
+	retentionMaxAge := parseSetting(l, "BOBBITD_RETENTION_MAX_AGE", durationAtLeast(0))
+	retentionFailedMaxAge := parseSetting(l, "BOBBITD_RETENTION_FAILED_MAX_AGE", durationAtLeast(0))
+	if retentionFailedMaxAge == 0 {
+		retentionFailedMaxAge = retentionMaxAge
+	}
+
+	c := BobbitDaemonConfig{
+		DBMaxOpenConn:     parseSetting(l, "BOBBITD_DB_MAX_OPEN_CONN", intAtLeast(0)),
+		DBMaxIdleConn:     parseSetting(l, "BOBBITD_DB_MAX_IDLE_CONN", intAtLeast(0)),
+		KillGracePeriod:   parseSetting(l, "BOBBITD_KILL_GRACE_PERIOD", durationAtLeast(0)),
+		MaxConcurrentJobs: parseSetting(l, "BOBBITD_MAX_CONCURRENT_JOBS", intAtLeast(0)),
+		QueueLimits:       parseSetting(l, "BOBBITD_QUEUE_LIMITS", ParseQueueLimits),
+		LogMaxSize:        parseSetting(l, "BOBBITD_LOG_MAX_SIZE", lib.ParseByteSize),
+		LogPolicy:         parseSetting(l, "BOBBITD_LOG_POLICY", parseLogPolicy),
+		LogMaxSegments:    parseSetting(l, "BOBBITD_LOG_MAX_SEGMENTS", intAtLeast(0)),
+		LogCompression:    parseSetting(l, "BOBBITD_LOG_COMPRESSION", joblog.ParseCompression),
+
+		RetentionMaxAge:       retentionMaxAge,
+		RetentionFailedMaxAge: retentionFailedMaxAge,
+		RetentionMaxPerName:   parseSetting(l, "BOBBITD_RETENTION_MAX_PER_NAME", intAtLeast(0)),
+		RetentionInterval:     parseSetting(l, "BOBBITD_RETENTION_INTERVAL", durationAtLeast(time.Second)),
+
+		AuthPolicyPath: parseSetting(l, "BOBBITD_AUTH_POLICY", parseString),
+		SocketMode:     parseSetting(l, "BOBBITD_SOCKET_MODE", parseFileMode),
+		SocketOwner:    parseSetting(l, "BOBBITD_SOCKET_OWNER", parseString),
+
+		ListenTCP:       parseSetting(l, "BOBBITD_LISTEN_TCP", parseString),
+		TLSCertPath:     parseSetting(l, "BOBBITD_TLS_CERT", parseString),
+		TLSKeyPath:      parseSetting(l, "BOBBITD_TLS_KEY", parseString),
+		TLSClientCAPath: parseSetting(l, "BOBBITD_TLS_CLIENT_CA", parseString),
+
+		HTTPListen:    parseSetting(l, "BOBBITD_HTTP_LISTEN", parseAddress),
+		MetricsListen: parseSetting(l, "BOBBITD_METRICS_LISTEN", parseAddress),
+
+		BobbitConfig: l.base(),
+	}
+
+	if c.ListenTCP != "" && (c.TLSCertPath == "" || c.TLSKeyPath == "" || c.TLSClientCAPath == "") {
+		l.errs = append(l.errs, fmt.Errorf("listen_tcp requires tls_cert, tls_key and tls_client_ca"))
+	}
+	if strings.HasPrefix(c.HTTPListen, "tcp://") && (c.TLSCertPath == "" || c.TLSKeyPath == "" || c.TLSClientCAPath == "") {
+		l.errs = append(l.errs, fmt.Errorf("http_listen over tcp:// requires tls_cert, tls_key and tls_client_ca"))
+	}
+	return c, errors.Join(l.errs...)
+}
+
+// Client resolves the configuration of bobbit. Every invalid setting is reported in the
+// returned error, and replaced by its default in the configuration.
+func (l *Loader) Client() (BobbitClientConfig, error) {
+	l.errs = nil
+
+	c := BobbitClientConfig{
+		Address:        parseSetting(l, "BOBBIT_ADDR", parseAddress),
+		TLSCertPath:    parseSetting(l, "BOBBIT_TLS_CERT", parseString),
+		TLSKeyPath:     parseSetting(l, "BOBBIT_TLS_KEY", parseString),
+		TLSCAPath:      parseSetting(l, "BOBBIT_TLS_CA", parseString),
+		TLSServerName:  parseSetting(l, "BOBBIT_TLS_SERVER_NAME", parseString),
+		DialTimeout:    parseSetting(l, "BOBBIT_DIAL_TIMEOUT", durationAtLeast(time.Millisecond)),
+		DialRetries:    parseSetting(l, "BOBBIT_DIAL_RETRIES", intAtLeast(0)),
+		DialRetryDelay: parseSetting(l, "BOBBIT_DIAL_RETRY_DELAY", durationAtLeast(0)),
+		BobbitConfig:   l.base(),
+	}
+	return c, errors.Join(l.errs...)
+}
+
+func (l *Loader) base() BobbitConfig {
+	return BobbitConfig{
+		DataPath:   parseSetting(l, "BOBBIT_DATA_DIR", parseString),
+		SocketPath: parseSetting(l, "BOBBIT_SOCKET_PATH", parseString),
+		DebugMode:  parseSetting(l, "DEBUG", parseDebug),
+	}
+}
+
+// parseSetting parses the value of the setting. An invalid value is recorded in the errors of
+// the loader, and the default value is returned instead.
+func parseSetting[T any](l *Loader, env string, parse func(string) (T, error)) T {
+	s, ok := lookupSetting(env)
+	if !ok {
+		panic("config: unknown setting " + env)
+	}
+
+	value, origin := l.Lookup(s)
+	v, err := parse(value)
+	if err == nil {
+		return v
+	}
+
+	l.errs = append(l.errs, fmt.Errorf("%s: %w", origin, err))
+	v, _ = parse(s.Default)
+	return v
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+// parseDebug enables debug mode for any value but false ones, e.g. `DEBUG=1` or `DEBUG=yes`.
+func parseDebug(s string) (bool, error) {
+	if enabled, err := strconv.ParseBool(s); err == nil {
+		return enabled, nil
+	}
+	return s != "", nil
+}
+
+func intAtLeast(min int) func(string) (int, error) {
+	return func(s string) (int, error) {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("invalid number: %q", s)
+		}
+		if n < min {
+			return 0, fmt.Errorf("invalid number: %q must be at least %d", s, min)
+		}
+		return n, nil
+	}
+}
+
+func durationAtLeast(min time.Duration) func(string) (time.Duration, error) {
+	return func(s string) (time.Duration, error) {
+		d, err := lib.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", s)
+		}
+		if d < min {
+			return 0, fmt.Errorf("invalid duration: %q must be at least %s", s, min)
+		}
+		return d, nil
+	}
+}
+
+func parseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid file mode: %q, expected octal permissions e.g. 0660", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+func parseLogPolicy(s string) (payload.LogPolicyEnum, error) {
+	switch policy := payload.LogPolicyEnum(s); policy {
+	case payload.LOG_POLICY_ROTATE, payload.LOG_POLICY_TRUNCATE, payload.LOG_POLICY_STOP:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid log policy: %q, expected rotate, truncate or stop", s)
+}
+
+// parseAddress validates an optional address, see ParseAddress.
+func parseAddress(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	if _, _, err := ParseAddress(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// readConfigFile reads the TOML configuration file into the values of its settings, keyed by
+// their environment variable. Keys that are not settings are errors, to catch typos.
+func readConfigFile(path string) (map[string]string, error) {
+	var doc map[string]any
+	if _, err := toml.DecodeFile(path, &doc); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	settings := map[string]Setting{}
+	for _, list := range [][]Setting{baseSettings, clientSettings, daemonSettings} {
+		for _, s := range list {
+			settings[s.Key] = s
+		}
+	}
+
+	values := map[string]string{}
+	var errs []error
+	var walk func(prefix string, table map[string]any)
+	walk = func(prefix string, table map[string]any) {
+		names := make([]string, 0, len(table))
+		for name := range table {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			key := prefix + name
+			s, ok := settings[key]
+			if !ok {
+				if section, isTable := table[name].(map[string]any); isTable && prefix == "" {
+					walk(key+".", section)
+					continue
+				}
+				errs = append(errs, fmt.Errorf("%s: unknown key %q", path, key))
+				continue
+			}
+
+			value, err := formatTOMLValue(s, table[name])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", path, key, err))
+				continue
+			}
+			values[s.Env] = value
+		}
+	}
+	walk("", doc)
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return values, nil
+}
+
+// formatTOMLValue converts a TOML value to the environment variable format of the setting.
+func formatTOMLValue(s Setting, v any) (string, error) {
+	switch value := v.(type) {
+	case string:
+		if s.Kind != SETTING_BOOL {
+			return value, nil
+		}
+	case int64:
+		switch s.Kind {
+		case SETTING_STRING, SETTING_INT:
+			return strconv.FormatInt(value, 10), nil
+		case SETTING_MODE:
+			return fmt.Sprintf("%04o", value), nil
+		}
+	case bool:
+		if s.Kind == SETTING_BOOL {
+			return strconv.FormatBool(value), nil
+		}
+	case map[string]any:
+		if s.Kind == SETTING_MAP {
+			pairs := make([]string, 0, len(value))
+			for name, limit := range value {
+				n, ok := limit.(int64)
+				if !ok {
+					return "", fmt.Errorf("expected an integer for %q, got %v", name, limit)
+				}
+				pairs = append(pairs, fmt.Sprintf("%s=%d", name, n))
+			}
+			sort.Strings(pairs)
+			return strings.Join(pairs, ","), nil
+		}
+	}
+	return "", fmt.Errorf("unexpected value %v, expected %s", v, s.Kind)
+}
+
+// WriteTOML writes the effective value of the settings as a configuration file, with the origin
+// of every value as comment. Settings of a section are written after the top-level ones.
+func (l *Loader) WriteTOML(w io.Writer, settings []Setting) error {
+	file := l.Path
+	if file == "" {
+		file = "none"
+	}
+	if _, err := fmt.Fprintf(w, "# Effective configuration, configuration file: %s\n", file); err != nil {
+		return err
+	}
+
+	section := ""
+	for _, s := range settings {
+		name := s.Key
+		if prefix, key, found := strings.Cut(s.Key, "."); found {
+			name = key
+			if prefix != section {
+				section = prefix
+				if _, err := fmt.Fprintf(w, "\n[%s]\n", section); err != nil {
+					return err
+				}
+			}
+		}
+
+		value, origin := l.Lookup(s)
+		if _, err := fmt.Fprintf(w, "%s = %s # %s\n", name, formatTOML(s, value), origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatTOML formats the value of the setting as a TOML value.
+func formatTOML(s Setting, value string) string {
+	switch s.Kind {
+	case SETTING_INT:
+		if _, err := strconv.Atoi(value); err == nil {
+			return value
+		}
+	case SETTING_BOOL:
+		enabled, _ := parseDebug(value)
+		return strconv.FormatBool(enabled)
+	case SETTING_MAP:
+		limits, err := ParseQueueLimits(value)
+		if err != nil {
+			break
+		}
+		pairs := make([]string, 0, len(limits))
+		for name, limit := range limits {
+			if !bareKey.MatchString(name) {
+				name = strconv.Quote(name)
+			}
+			pairs = append(pairs, fmt.Sprintf("%s = %d", name, limit))
+		}
+		if len(pairs) == 0 {
+			return "{}"
+		}
+		sort.Strings(pairs)
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	}
+	return strconv.Quote(value)
+}
+
+// bareKey matches the TOML keys that do not need quotes.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
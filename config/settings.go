@@ -0,0 +1,127 @@
+package config
+
+import "strings"
+
+// SettingKind is the TOML type of a setting in the configuration file.
+type SettingKind int
+
+const (
+	// SETTING_STRING is a string, also used for durations and sizes, e.g. `"10s"` or `"100M"`.
+	SETTING_STRING SettingKind = iota
+	// SETTING_INT is an integer.
+	SETTING_INT
+	// SETTING_BOOL is a boolean.
+	SETTING_BOOL
+	// SETTING_MODE is a file mode, either an octal string `"0660"` or an octal integer `0o660`.
+	SETTING_MODE
+	// SETTING_MAP is a table of integers, e.g. `{ build = 2 }`, given as `build=2` in flags and
+	// environment variables.
+	SETTING_MAP
+)
+
+// String returns the name of the TOML type, used in errors.
+func (k SettingKind) String() string {
+	switch k {
+	case SETTING_INT:
+		return "an integer"
+	case SETTING_BOOL:
+		return "a boolean"
+	case SETTING_MODE:
+		return "an octal string or integer"
+	case SETTING_MAP:
+		return "a table of integers"
+	}
+	return "a string"
+}
+
+// Setting is a configuration parameter. Its value is taken from a flag, an environment variable
+// or the configuration file, in this order, or else from its default.
+type Setting struct {
+	// Env is the environment variable of the setting, e.g. `BOBBITD_LOG_POLICY`.
+	Env string
+	// Key is the key in the configuration file, in `section.name` format inside a section.
+	Key string
+	// Kind is the TOML type of the value.
+	Kind SettingKind
+	// Default is the value of the setting when it is not set, in its environment variable format.
+	Default string
+	// Usage describes the setting, used as help of its flag.
+	Usage string
+}
+
+// Flag returns the name of the flag of the setting, the key without its section and with dashes,
+// e.g. `log-policy` for `daemon.log_policy`.
+func (s Setting) Flag() string {
+	name := s.Key
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// baseSettings are shared by the daemon and the client, see BobbitConfig.
+var baseSettings = []Setting{
+	{"BOBBIT_SOCKET_PATH", "socket_path", SETTING_STRING, "/tmp/bobbitd.sock", "Path of the socket"},
+	{"BOBBIT_DATA_DIR", "data_dir", SETTING_STRING, "/tmp/bobbitd/", "Directory of the metadata database and the job logs"},
+	{"DEBUG", "debug", SETTING_BOOL, "", "Enable verbose logging"},
+}
+
+// clientSettings are the settings of BobbitClientConfig.
+var clientSettings = []Setting{
+	{"BOBBIT_ADDR", "client.address", SETTING_STRING, "", "Address of the daemon, unix:///path/to/socket or tcp://host:port"},
+	{"BOBBIT_TLS_CERT", "client.tls_cert", SETTING_STRING, "", "Client certificate in PEM format, for tcp:// addresses"},
+	{"BOBBIT_TLS_KEY", "client.tls_key", SETTING_STRING, "", "Private key of the client certificate"},
+	{"BOBBIT_TLS_CA", "client.tls_ca", SETTING_STRING, "", "CA in PEM format verifying the certificate of the daemon"},
+	{"BOBBIT_TLS_SERVER_NAME", "client.tls_server_name", SETTING_STRING, "", "Name verified in the certificate of the daemon"},
+	{"BOBBIT_DIAL_TIMEOUT", "client.dial_timeout", SETTING_STRING, "5s", "Time to connect to the daemon"},
+	{"BOBBIT_DIAL_RETRIES", "client.dial_retries", SETTING_INT, "2", "Extra attempts to connect while the daemon is unavailable"},
+	{"BOBBIT_DIAL_RETRY_DELAY", "client.dial_retry_delay", SETTING_STRING, "200ms", "Time between two attempts to connect"},
+}
+
+// daemonSettings are the settings of BobbitDaemonConfig.
+var daemonSettings = []Setting{
+	{"BOBBITD_DB_MAX_OPEN_CONN", "daemon.db_max_open_conn", SETTING_INT, "1", "Maximum number of open connections to the database"},
+	{"BOBBITD_DB_MAX_IDLE_CONN", "daemon.db_max_idle_conn", SETTING_INT, "1", "Maximum number of idle connections to the database"},
+	{"BOBBITD_KILL_GRACE_PERIOD", "daemon.kill_grace_period", SETTING_STRING, "10s", "Time between SIGTERM and SIGKILL when a job exceeds its timeout"},
+	{"BOBBITD_MAX_CONCURRENT_JOBS", "daemon.max_concurrent_jobs", SETTING_INT, "0", "Maximum number of running jobs, 0 is unlimited"},
+	{"BOBBITD_QUEUE_LIMITS", "daemon.queue_limits", SETTING_MAP, "", "Maximum number of running jobs per queue, e.g. build=2,deploy=1"},
+	{"BOBBITD_LOG_MAX_SIZE", "daemon.log_max_size", SETTING_STRING, "0", "Maximum size of a job log, e.g. 100M, 0 is unlimited"},
+	{"BOBBITD_LOG_POLICY", "daemon.log_policy", SETTING_STRING, "rotate", "What happens when a job log reaches its maximum size: rotate, truncate or stop"},
+	{"BOBBITD_LOG_MAX_SEGMENTS", "daemon.log_max_segments", SETTING_INT, "5", "Number of rotated segments kept per job log"},
+	{"BOBBITD_LOG_COMPRESSION", "daemon.log_compression", SETTING_STRING, "none", "Compression of finished job logs: none, gzip or zstd"},
+	{"BOBBITD_RETENTION_MAX_AGE", "daemon.retention_max_age", SETTING_STRING, "0", "Delete finished jobs older than this duration, e.g. 30d, 0 keeps them forever"},
+	{"BOBBITD_RETENTION_FAILED_MAX_AGE", "daemon.retention_failed_max_age", SETTING_STRING, "0", "Retention of failed, timed out and lost jobs, 0 follows retention-max-age"},
+	{"BOBBITD_RETENTION_MAX_PER_NAME", "daemon.retention_max_per_name", SETTING_INT, "0", "Keep only the latest N finished jobs per job name, 0 is unlimited"},
+	{"BOBBITD_RETENTION_INTERVAL", "daemon.retention_interval", SETTING_STRING, "1h", "Time between two retention runs"},
+	{"BOBBITD_AUTH_POLICY", "daemon.auth_policy", SETTING_STRING, "", "Path of the JSON authorization policy"},
+	{"BOBBITD_SOCKET_MODE", "daemon.socket_mode", SETTING_MODE, "0", "Permissions of the socket in octal, e.g. 0660, 0 follows the umask"},
+	{"BOBBITD_SOCKET_OWNER", "daemon.socket_owner", SETTING_STRING, "", "Owner of the socket as user:group, by name or ID"},
+	{"BOBBITD_LISTEN_TCP", "daemon.listen_tcp", SETTING_STRING, "", "Address of the TCP listener for remote clients, e.g. :7420"},
+	{"BOBBITD_TLS_CERT", "daemon.tls_cert", SETTING_STRING, "", "Certificate of the TCP listener in PEM format"},
+	{"BOBBITD_TLS_KEY", "daemon.tls_key", SETTING_STRING, "", "Private key of the TCP listener certificate"},
+	{"BOBBITD_TLS_CLIENT_CA", "daemon.tls_client_ca", SETTING_STRING, "", "CA in PEM format verifying the certificates of remote clients"},
+	{"BOBBITD_HTTP_LISTEN", "daemon.http_listen", SETTING_STRING, "", "Address of the HTTP gateway, unix:///path/to/socket or tcp://host:port"},
+	{"BOBBITD_METRICS_LISTEN", "daemon.metrics_listen", SETTING_STRING, "", "Address serving the Prometheus metrics, unix:///path/to/socket or tcp://host:port"},
+}
+
+// DaemonSettings returns the settings of bobbitd, in the order of the configuration file.
+func DaemonSettings() []Setting {
+	return append(append([]Setting(nil), baseSettings...), daemonSettings...)
+}
+
+// ClientSettings returns the settings of bobbit, in the order of the configuration file.
+func ClientSettings() []Setting {
+	return append(append([]Setting(nil), baseSettings...), clientSettings...)
+}
+
+// lookupSetting finds a setting of either the daemon or the client by its environment variable.
+func lookupSetting(env string) (Setting, bool) {
+	for _, list := range [][]Setting{baseSettings, clientSettings, daemonSettings} {
+		for _, s := range list {
+			if s.Env == env {
+				return s, true
+			}
+		}
+	}
+	return Setting{}, false
+}
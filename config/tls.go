@@ -0,0 +1,45 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// LoadTLSConfig builds a mutual TLS configuration from PEM files. The certificate and key
+// identify this side of the connection, and the CA verifies the other side.
+//
+// For the daemon, clients must present a certificate signed by the CA. For the client,
+// the CA verifies the certificate of the daemon.
+func LoadTLSConfig(certPath string, keyPath string, caPath string, server bool) (*tls.Config, error) {
+	if certPath == "" || keyPath == "" || caPath == "" {
+		return nil, fmt.Errorf("certificate, key and CA are required for TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate found in CA %s", caPath)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if server {
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,243 @@
+package daemon
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// PeerCredentials identifies the process on the other side of a client connection.
+// Remote clients have no process, so they are identified by the Name of their certificate.
+type PeerCredentials struct {
+	PID int
+	UID int
+	GID int
+	// Name is the Common Name of the client certificate. It is only set for remote clients.
+	Name string
+}
+
+// Remote reports whether the client is connected over TCP, see readCertificateCredentials.
+func (p *PeerCredentials) Remote() bool {
+	return p.Name != ""
+}
+
+// readCertificateCredentials completes the TLS handshake and identifies the remote client by the
+// Common Name of its verified certificate. Remote clients do not own local users, so UID and GID are -1.
+func readCertificateCredentials(conn *tls.Conn) (*PeerCredentials, error) {
+	// Connections of the HTTP gateway are already handshaked by http.Server, whose deadlines
+	// must be kept
+	if !conn.ConnectionState().HandshakeComplete {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		err := conn.Handshake()
+		conn.SetDeadline(time.Time{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 || certs[0].Subject.CommonName == "" {
+		return nil, fmt.Errorf("client certificate has no common name")
+	}
+	return &PeerCredentials{UID: -1, GID: -1, Name: certs[0].Subject.CommonName}, nil
+}
+
+// authActionEnum defines the actions controlled by the authorization policy, see config.AuthRule.
+type authActionEnum string
+
+const (
+	AUTH_STOP authActionEnum = "stop"
+	AUTH_LOGS authActionEnum = "logs"
+	AUTH_LIST authActionEnum = "list"
+)
+
+// AuthorizeMetrics returns an error if the client may not read the metrics. The metrics cover
+// every job, so the client must be allowed to list every job.
+func (d *DaemonStruct) AuthorizeMetrics(peer *PeerCredentials) error {
+	jc := &JobContext{daemon: d, Peer: peer, rule: d.authRule(peer)}
+	return jc.authorizeAll(AUTH_LIST)
+}
+
+// authScopeRank orders the scopes from the least to the most permissive.
+var authScopeRank = map[config.AuthScopeEnum]int{
+	config.AUTH_SCOPE_NONE: 0,
+	config.AUTH_SCOPE_OWN:  1,
+	config.AUTH_SCOPE_ALL:  2,
+}
+
+// authRule resolves the rule of the client from the authorization policy.
+// Every field of the returned rule is set.
+func (d *DaemonStruct) authRule(peer *PeerCredentials) config.AuthRule {
+	policy := d.live().authPolicy
+	allowed := true
+	if policy == nil {
+		return config.AuthRule{Create: &allowed, Stop: config.AUTH_SCOPE_ALL, Logs: config.AUTH_SCOPE_ALL, List: config.AUTH_SCOPE_ALL}
+	}
+	if peer == nil {
+		// Without credentials, the client cannot be matched to any rule
+		denied := false
+		return config.AuthRule{Create: &denied, Stop: config.AUTH_SCOPE_NONE, Logs: config.AUTH_SCOPE_NONE, List: config.AUTH_SCOPE_NONE}
+	}
+	if !peer.Remote() && (peer.UID == 0 || peer.UID == os.Getuid()) {
+		return config.AuthRule{Create: &allowed, Stop: config.AUTH_SCOPE_ALL, Logs: config.AUTH_SCOPE_ALL, List: config.AUTH_SCOPE_ALL}
+	}
+
+	var rule config.AuthRule
+	if peer.Remote() {
+		// Remote clients are matched by the name of their certificate only
+		rule = policy.Users[peer.Name]
+	} else {
+		rule = localAuthRule(policy, peer)
+	}
+
+	// Unset fields fall back to the default rule, then to acting on the own jobs
+	fallback := policy.Default
+	if fallback.Create == nil {
+		fallback.Create = &allowed
+	}
+	if rule.Create == nil {
+		rule.Create = fallback.Create
+	}
+	for _, scope := range []struct{ rule, fallback *config.AuthScopeEnum }{
+		{&rule.Stop, &fallback.Stop},
+		{&rule.Logs, &fallback.Logs},
+		{&rule.List, &fallback.List},
+	} {
+		if *scope.rule == "" {
+			*scope.rule = *scope.fallback
+		}
+		if *scope.rule == "" {
+			*scope.rule = config.AUTH_SCOPE_OWN
+		}
+	}
+
+	return rule
+}
+
+// localAuthRule finds the rule of a local user in the policy, by user or else by its groups.
+func localAuthRule(policy *config.AuthPolicy, peer *PeerCredentials) config.AuthRule {
+	uid := strconv.Itoa(peer.UID)
+	gids := []string{strconv.Itoa(peer.GID)}
+	names := map[string]string{}
+	if u, err := user.LookupId(uid); err == nil {
+		names[uid] = u.Username
+		if groupIDs, err := u.GroupIds(); err == nil {
+			gids = append(gids, groupIDs...)
+		}
+	}
+
+	var rule config.AuthRule
+	if r, ok := policy.Users[uid]; ok {
+		rule = r
+	} else if r, ok := policy.Users[names[uid]]; ok && names[uid] != "" {
+		rule = r
+	} else {
+		for _, gid := range gids {
+			r, ok := policy.Groups[gid]
+			if !ok {
+				if g, err := user.LookupGroupId(gid); err == nil {
+					r, ok = policy.Groups[g.Name]
+				}
+			}
+			if ok {
+				rule = mergeAuthRules(rule, r)
+			}
+		}
+	}
+
+	return rule
+}
+
+// mergeAuthRules returns the most permissive combination of both rules.
+func mergeAuthRules(a config.AuthRule, b config.AuthRule) config.AuthRule {
+	if b.Create != nil && (a.Create == nil || *b.Create) {
+		a.Create = b.Create
+	}
+	if authScopeRank[b.Stop] > authScopeRank[a.Stop] || a.Stop == "" {
+		a.Stop = b.Stop
+	}
+	if authScopeRank[b.Logs] > authScopeRank[a.Logs] || a.Logs == "" {
+		a.Logs = b.Logs
+	}
+	if authScopeRank[b.List] > authScopeRank[a.List] || a.List == "" {
+		a.List = b.List
+	}
+	return a
+}
+
+// Owner returns the client as owner of the jobs it submits, or nil if its credentials are unknown.
+// Jobs of remote clients have no owner, so the AUTH_SCOPE_OWN scope does not cover any job for them.
+func (jc *JobContext) Owner() *payload.JobOwner {
+	if jc.Peer == nil || jc.Peer.Remote() {
+		return nil
+	}
+	return &payload.JobOwner{UID: jc.Peer.UID, GID: jc.Peer.GID}
+}
+
+// scope returns the scope of the action allowed to the client.
+func (jc *JobContext) scope(action authActionEnum) config.AuthScopeEnum {
+	switch action {
+	case AUTH_STOP:
+		return jc.rule.Stop
+	case AUTH_LOGS:
+		return jc.rule.Logs
+	case AUTH_LIST:
+		return jc.rule.List
+	}
+	return config.AUTH_SCOPE_NONE
+}
+
+// authorizeCreate returns an error if the client may not submit jobs.
+func (jc *JobContext) authorizeCreate() error {
+	if jc.rule.Create == nil || !*jc.rule.Create {
+		return jc.forbidden("create")
+	}
+	return nil
+}
+
+// authorizeAll returns an error if the client may not do the action on every job.
+func (jc *JobContext) authorizeAll(action authActionEnum) error {
+	if jc.scope(action) != config.AUTH_SCOPE_ALL {
+		return jc.forbidden(string(action) + " every job")
+	}
+	return nil
+}
+
+// ownerFilter returns the user whose jobs the client may do the action on, to be used
+// as models.JobFilter.OwnerUID. It is nil if the client may do it on every job.
+func (jc *JobContext) ownerFilter(action authActionEnum) (*int, error) {
+	switch jc.scope(action) {
+	case config.AUTH_SCOPE_ALL:
+		return nil, nil
+	case config.AUTH_SCOPE_OWN:
+		return &jc.Peer.UID, nil
+	}
+	return nil, jc.forbidden(string(action))
+}
+
+// allows reports whether the client may do the action on a job or schedule of the owner.
+func (jc *JobContext) allows(action authActionEnum, owner *payload.JobOwner) bool {
+	switch jc.scope(action) {
+	case config.AUTH_SCOPE_ALL:
+		return true
+	case config.AUTH_SCOPE_OWN:
+		return owner != nil && owner.UID == jc.Peer.UID
+	}
+	return false
+}
+
+func (jc *JobContext) forbidden(action string) error {
+	client := "unknown user"
+	if jc.Peer != nil && jc.Peer.Remote() {
+		client = fmt.Sprintf("remote client %s", jc.Peer.Name)
+	} else if jc.Peer != nil {
+		client = fmt.Sprintf("uid %d", jc.Peer.UID)
+	}
+	return &DaemonError{"Forbidden", fmt.Errorf("%s is not allowed to %s", client, action), payload.ERROR_FORBIDDEN}
+}
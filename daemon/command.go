@@ -1,22 +1,25 @@
 package daemon
 
 import (
+	"bufio"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net"
 	"os"
-	"os/exec"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/internal/dblib"
+	"github.com/mplus-oss/bobbit.go/internal/joblog"
 	"github.com/mplus-oss/bobbit.go/internal/lib"
 	"github.com/mplus-oss/bobbit.go/metadata/models"
 	"github.com/mplus-oss/bobbit.go/payload"
-	"github.com/nxadm/tail"
 )
 
 type HandlerFunc func(jc *JobContext) error
@@ -24,131 +27,223 @@ type HandlerFunc func(jc *JobContext) error
 // HandleVibeCheck handles a "vibe check" request, which typically serves as a basic
 // ping to confirm the daemon is responsive. It unmarshals the request metadata.
 func (d *DaemonStruct) HandleVibeCheck(jc *JobContext) error {
-	var payload payload.PayloadRegularMetadata
-	if err := jc.Payload.UnmarshalMetadata(&payload); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err}
+	var metadata payload.PayloadRegularMetadata
+	if err := jc.Payload.UnmarshalMetadata(&metadata); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
 	}
 	return nil
 }
 
-// HandleJob processes a new job request. It validates the job payload,
-// generates a unique ID if not provided, creates necessary data files (lock, log, metadata),
-// executes the command, captures its output and exit code, and cleans up the lock file.
+// HandleJob processes a new job request. It submits the job to the queue and
+// responds with the queued job. The dispatcher executes the command once a slot
+// is available.
+//
+// See: DaemonStruct.SubmitJob
 func (d *DaemonStruct) HandleJob(jc *JobContext) error {
 	var p payload.JobDetailMetadata
 	if err := jc.Payload.UnmarshalMetadata(&p); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err}
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+	if err := jc.authorizeCreate(); err != nil {
+		return err
+	}
+
+	// Set timestamp if not provided. This is for logfile path.
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = jc.Payload.Timestamp
+		p.UpdatedAt = jc.Payload.Timestamp
+	}
+
+	respPayload, err := d.SubmitJob(p, jc.Owner())
+	if err != nil {
+		return err
+	}
+
+	if err := jc.SendPayload(respPayload); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
 	}
 
+	return nil
+}
+
+// SubmitJob validates the job payload, generates a unique ID if not provided,
+// creates the logfile and stores the job as JOB_QUEUED. The dispatcher is woken up
+// so the job is executed as soon as the concurrency limits allow it.
+// The owner is the user who submitted the job, or nil if unknown.
+func (d *DaemonStruct) SubmitJob(p payload.JobDetailMetadata, owner *payload.JobOwner) (*payload.JobResponse, error) {
 	if p.JobName == "" || len(p.Command) < 1 {
-		return &DaemonError{"Invalid p: JobName or Command not provided", nil}
+		return nil, &DaemonError{"Invalid p: JobName or Command not provided", nil, payload.ERROR_INVALID_REQUEST}
 	}
 
 	// Generate a unique ID if not provided
 	if p.ID == "" {
 		hash, err := lib.GenerateRandomHash(32)
 		if err != nil {
-			return &DaemonError{"Failed to create Hash for job", err}
+			return nil, &DaemonError{"Failed to create Hash for job", err, payload.ERROR_INTERNAL}
 		}
 		p.ID = hash
 	}
 
-	// Set timestamp if not provided. This is for logfile path.
 	if p.CreatedAt.IsZero() {
-		p.CreatedAt = jc.Payload.Timestamp
-		p.UpdatedAt = jc.Payload.Timestamp
+		p.CreatedAt = time.Now()
+		p.UpdatedAt = p.CreatedAt
 	}
 
-	// Marshal and write job metadata
-	metadataStr := ""
-	if p.Metadata != nil {
-		metaByte, err := json.Marshal(p.Metadata)
+	// Validate the working directory before the job is acknowledged
+	if p.WorkDir != "" {
+		info, err := os.Stat(p.WorkDir)
 		if err != nil {
-			return &DaemonPayloadError{"Failed to marshal metadata", p.ID, err}
+			return nil, &DaemonPayloadError{"Invalid working directory", p.ID, err, payload.ERROR_INVALID_REQUEST}
+		}
+		if !info.IsDir() {
+			return nil, &DaemonPayloadError{"Invalid working directory", p.ID, fmt.Errorf("%s is not a directory", p.WorkDir), payload.ERROR_INVALID_REQUEST}
+		}
+	}
+	if p.Delay < 0 {
+		return nil, &DaemonPayloadError{"Invalid delay", p.ID, fmt.Errorf("delay must not be negative"), payload.ERROR_INVALID_REQUEST}
+	}
+	if p.LogMaxSize < 0 {
+		return nil, &DaemonPayloadError{"Invalid log size", p.ID, fmt.Errorf("log max size must not be negative"), payload.ERROR_INVALID_REQUEST}
+	}
+	switch p.LogPolicy {
+	case "", payload.LOG_POLICY_ROTATE, payload.LOG_POLICY_TRUNCATE, payload.LOG_POLICY_STOP:
+	default:
+		return nil, &DaemonPayloadError{"Invalid log policy", p.ID, fmt.Errorf("unknown policy: %s", p.LogPolicy), payload.ERROR_INVALID_REQUEST}
+	}
+	if p.Timeout < 0 || p.KillGracePeriod < 0 {
+		return nil, &DaemonPayloadError{"Invalid timeout", p.ID, fmt.Errorf("timeout and kill grace period must not be negative"), payload.ERROR_INVALID_REQUEST}
+	}
+	if r := p.Retry; r != nil {
+		if r.MaxAttempts < 1 || r.Delay < 0 || r.MaxDelay < 0 {
+			return nil, &DaemonPayloadError{"Invalid retry policy", p.ID, fmt.Errorf("max attempts must be positive and delays must not be negative"), payload.ERROR_INVALID_REQUEST}
+		}
+		switch r.Backoff {
+		case "", payload.RETRY_BACKOFF_FIXED, payload.RETRY_BACKOFF_EXPONENTIAL:
+		default:
+			return nil, &DaemonPayloadError{"Invalid retry policy", p.ID, fmt.Errorf("unknown backoff: %s", r.Backoff), payload.ERROR_INVALID_REQUEST}
+		}
+	}
+	for k := range p.Env {
+		if k == "" || strings.ContainsAny(k, "=\x00") {
+			return nil, &DaemonPayloadError{"Invalid environment variable name", p.ID, fmt.Errorf("key: %q", k), payload.ERROR_INVALID_REQUEST}
 		}
-
-		metadataStr = string(metaByte)
 	}
 
-	// Prepare the logfile
-	logFile := config.GenerateJobLogPath(jc.daemon.BobbitConfig, p)
-	logOutput, err := os.Create(logFile)
+	jobModel, err := models.NewJobModel(d.DB, payload.JobResponse{})
 	if err != nil {
-		return &DaemonPayloadError{"Failed to create logfile", p.ID, err}
+		return nil, &DaemonPayloadError{"Failed when initialize db model", p.ID, err, payload.ERROR_INTERNAL}
 	}
-	defer logOutput.Close()
 
-	if len(p.Command) == 0 {
-		return &DaemonPayloadError{"No command provided", p.ID, err}
+	// Jobs with dependencies wait as pending until their upstream jobs are finished
+	status := payload.JOB_QUEUED
+	if len(p.DependsOn) > 0 {
+		switch p.DependencyCondition {
+		case "":
+			p.DependencyCondition = payload.DEPENDENCY_ON_SUCCESS
+		case payload.DEPENDENCY_ON_SUCCESS, payload.DEPENDENCY_ON_COMPLETION, payload.DEPENDENCY_ON_FAILURE:
+		default:
+			return nil, &DaemonPayloadError{"Invalid dependency condition", p.ID, fmt.Errorf("unknown condition: %s", p.DependencyCondition), payload.ERROR_INVALID_REQUEST}
+		}
+
+		dependsOnIDs, err := d.resolveDependencies(jobModel, p.ID, p.DependsOn)
+		if err != nil {
+			return nil, err
+		}
+		p.DependsOn = dependsOnIDs
+		status = payload.JOB_PENDING
+	}
+
+	// Delayed jobs are kept as scheduled until the dispatcher promotes them
+	if p.NotBefore == nil && p.Delay > 0 {
+		notBefore := time.Now().Add(p.Delay)
+		p.NotBefore = &notBefore
+	}
+	if p.NotBefore != nil {
+		notBefore := p.NotBefore.UTC()
+		p.NotBefore = &notBefore
+		if notBefore.After(time.Now()) {
+			status = payload.JOB_SCHEDULED
+		}
 	}
 
+	// Prepare the logfile, so the log can be tailed while the job is queued
+	logFile := config.GenerateJobLogPath(d.BobbitConfig, p)
+	logOutput, err := os.Create(logFile)
+	if err != nil {
+		return nil, &DaemonPayloadError{"Failed to create logfile", p.ID, err, payload.ERROR_INTERNAL}
+	}
+	logOutput.Close()
+
 	// Begin the response structure
 	respPayload := &payload.JobResponse{
 		ExitCode:          -1,
-		Status:            payload.JOB_NOT_RUNNING,
+		Status:            status,
+		Owner:             owner,
 		JobDetailMetadata: p,
 	}
 
-	// Return the response before executing code
-	if err := jc.SendPayload(respPayload); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to send payload", err}
-	}
-
-	// Save the process
-	job, err := models.NewJobModel(jc.daemon.DB, *respPayload)
+	// Save the job into the queue
+	job, err := models.NewJobModel(d.DB, *respPayload)
 	if err != nil {
-		return &DaemonPayloadError{"Failed when initialize db model", p.ID, err}
+		return nil, &DaemonPayloadError{"Failed when initialize db model", p.ID, err, payload.ERROR_INTERNAL}
 	}
+	job.LogFormat = joblog.FORMAT_FRAMED
 	if err := job.Save(); err != nil {
-		return &DaemonPayloadError{"Failed when creating job record", p.ID, err}
+		return nil, &DaemonPayloadError{"Failed when creating job record", p.ID, err, payload.ERROR_INTERNAL}
+	}
+	if len(p.DependsOn) > 0 {
+		if err := job.SaveDependencies(p.DependsOn); err != nil {
+			job.Delete()
+			return nil, &DaemonPayloadError{"Failed when creating job dependencies", p.ID, err, payload.ERROR_INTERNAL}
+		}
 	}
 
-	// Prep the output
-	cmd := exec.Command(p.Command[0], p.Command[1:]...)
-	cmd.Stdout = logOutput
-	cmd.Stderr = logOutput
-
-	// Make it as a different group
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	d.publishJobEventAs(payload.EVENT_CREATED, job)
+	d.wakeDispatcher()
+	return respPayload, nil
+}
 
-	// Add job-specific environment variables
-	cmd.Env = append(
-		os.Environ(),
-		fmt.Sprintf("JOB_ID=%s", p.ID),
-		fmt.Sprintf("JOB_NAME=%s", p.JobName),
-		fmt.Sprintf("JOB_METADATA=%s", metadataStr),
-	)
+// buildJobEnv returns the base environment of the job process. The daemon environment
+// is included only if the job inherits it, and the job overrides are appended in key order
+// so they take precedence over inherited variables.
+func buildJobEnv(p payload.JobDetailMetadata) []string {
+	env := []string{}
+	if p.ShouldInheritEnv() {
+		env = append(env, os.Environ()...)
+	}
 
-	log.Printf("Starting Job: %+v", p)
-	if err := cmd.Start(); err != nil {
-		job.Delete()
-		return &DaemonPayloadError{"Failed when starting command", p.ID, err}
+	keys := make([]string, 0, len(p.Env))
+	for k := range p.Env {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	job.Status = int(payload.JOB_RUNNING)
-	job.PID = cmd.Process.Pid
-	if err := job.Update(); err != nil {
-		log.Printf("[WARNING] Failed when updating status: %+v", err)
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, p.Env[k]))
 	}
+	return env
+}
 
-	if err := cmd.Wait(); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			job.ExitCode = exitErr.ExitCode()
-		} else {
-			job.ExitCode = 127
+// fillQueuePositions sets JobResponse.QueuePosition of every queued job.
+func fillQueuePositions(jobModel *models.JobModel, jobs ...*payload.JobResponse) error {
+	hasQueued := false
+	for _, job := range jobs {
+		if job.Status == payload.JOB_QUEUED {
+			hasQueued = true
+			break
 		}
-	} else {
-		job.ExitCode = 0
 	}
-
-	if err := job.MarkJobFinished(); err != nil {
-		return &DaemonPayloadError{"Failed to update job", p.ID, err}
+	if !hasQueued {
+		return nil
 	}
 
-	if job.ExitCode > 0 {
-		return &DaemonPayloadError{"Exit with code", p.ID, fmt.Errorf("code: %d", job.ExitCode)}
+	positions, err := jobModel.QueuePositions()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		job.QueuePosition = positions[job.ID]
 	}
-
 	return nil
 }
 
@@ -160,17 +255,24 @@ func (d *DaemonStruct) HandleJob(jc *JobContext) error {
 func (d *DaemonStruct) ListJob(jc *JobContext) error {
 	var req payload.JobSearchMetadata
 	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err}
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+
+	ownerUID, err := jc.ownerFilter(AUTH_LIST)
+	if err != nil {
+		return err
 	}
 
 	job, err := models.NewJobModel(jc.daemon.DB, payload.JobResponse{})
 	if err != nil {
-		return &DaemonError{"Failed when initialize db model", err}
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
 	}
 
 	filter := &models.JobFilter{
+		OwnerUID:             ownerUID,
 		ActiveOnly:           req.ActiveOnly,
 		FinishOnly:           req.FinishOnly,
+		QueuedOnly:           req.QueuedOnly,
 		MetadataFilter:       req.MetadataFilter,
 		GeneralKeywordSearch: req.Search,
 		HideCommand:          req.HideCommand,
@@ -189,11 +291,11 @@ func (d *DaemonStruct) ListJob(jc *JobContext) error {
 	if req.NumberOnly {
 		jobCount, err := job.Count(filter)
 		if err != nil {
-			return &DaemonError{"Failed when counting the job", err}
+			return &DaemonError{"Failed when counting the job", err, payload.ERROR_INTERNAL}
 		}
 
 		if err := jc.SendPayload(payload.JobResponseCount{Count: jobCount}); err != nil {
-			return &DaemonError{"Invalid metadata: Failed to send payload", err}
+			return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
 		}
 
 		return nil
@@ -201,65 +303,110 @@ func (d *DaemonStruct) ListJob(jc *JobContext) error {
 
 	rawJobs, err := job.Get(filter)
 	if err != nil {
-		return &DaemonError{"Failed when fetch the job", err}
+		return &DaemonError{"Failed when fetch the job", err, payload.ERROR_INTERNAL}
 	}
 	jobs, err := job.BulkToPayload(rawJobs)
 	if err != nil {
-		return &DaemonError{"Failed when transforming raw job", err}
+		return &DaemonError{"Failed when transforming raw job", err, payload.ERROR_INTERNAL}
+	}
+	if err := fillQueuePositions(job, jobs...); err != nil {
+		return &DaemonError{"Failed when computing queue positions", err, payload.ERROR_INTERNAL}
 	}
 
 	if err := jc.SendPayload(jobs); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to send payload", err}
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
 	}
 
 	return nil
 }
 
-// WaitJob handles requests to wait for a specific job to complete.
-// It continuously checks for the absence of the job's lock file and
-// responds to the client with the job's final status once completed.
-// It also handles client connection timeouts.
+// WaitJob handles requests to wait for one or multiple jobs to complete.
+// It is woken up by the job events instead of polling the database, and responds
+// with WaitResponse once the jobs are finished or the deadline has passed.
+// Requests with a single search get the job's JobResponse instead, unless the deadline has passed.
+//
+// Check payload.WaitRequestMetadata for more information.
 func (d *DaemonStruct) WaitJob(jc *JobContext) error {
-	var req payload.JobSearchMetadata
+	var req payload.WaitRequestMetadata
 	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err}
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+	if req.Search == "" && len(req.Targets) == 0 && len(req.MetadataFilter) == 0 {
+		return &DaemonError{"Invalid metadata: Nothing to wait for", fmt.Errorf("targets or metadata filter is required"), payload.ERROR_INVALID_REQUEST}
+	}
+	switch req.Mode {
+	case "", payload.WAIT_ALL, payload.WAIT_ANY:
+	default:
+		return &DaemonError{"Invalid wait mode", fmt.Errorf("unknown mode: %s", req.Mode), payload.ERROR_INVALID_REQUEST}
+	}
+	single := req.Search != "" && len(req.Targets) == 0 && len(req.MetadataFilter) == 0
+	ownerUID, err := jc.ownerFilter(AUTH_LIST)
+	if err != nil {
+		return err
 	}
 
 	jobModel, err := models.NewJobModel(jc.daemon.DB, payload.JobResponse{})
 	if err != nil {
-		return &DaemonError{"Failed when initialize db model", err}
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
 	}
 
-	filter := &models.JobFilter{
-		GeneralKeywordSearch: req.Search,
-		DBGetFilter: models.DBGetFilter{
-			Limit:    1,
-			SortDesc: true,
-		},
-	}
 	ctx, cancel := context.WithCancel(context.Background())
-	rawJob, err := jobModel.WaitJob(ctx, cancel, filter)
-	if err != nil {
-		return &DaemonError{"Failed when waiting the job", err}
+	defer cancel()
+	if req.Deadline != nil {
+		ctx, cancel = context.WithDeadline(ctx, *req.Deadline)
+		defer cancel()
 	}
-	job, err := rawJob.ToPayload()
-	if err != nil {
-		return &DaemonError{"Failed when transforming job", err}
+
+	// Stop waiting once the client disconnects, since no more data is expected from the client
+	disconnected := make(chan struct{})
+	go func() {
+		buf := make([]byte, 1)
+		jc.conn.Read(buf)
+		close(disconnected)
+		cancel()
+	}()
+
+	rawJobs, err := d.waitJobs(ctx, jobModel, req, ownerUID)
+	select {
+	case <-disconnected:
+		return nil
+	default:
+	}
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+	if err != nil && !timedOut {
+		return &DaemonError{"Failed when waiting the job", err, payload.ERROR_INTERNAL}
 	}
 
-	if err := jc.SendPayload(job); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to send payload", err}
+	var resp any
+	if single && !timedOut && len(rawJobs) > 0 {
+		job, err := rawJobs[0].ToPayload()
+		if err != nil {
+			return &DaemonError{"Failed when transforming job", err, payload.ERROR_INTERNAL}
+		}
+		resp = job
+	} else {
+		jobs, err := jobModel.BulkToPayload(rawJobs)
+		if err != nil {
+			return &DaemonError{"Failed when transforming job", err, payload.ERROR_INTERNAL}
+		}
+		waitResp := payload.WaitResponse{TimedOut: timedOut, Jobs: []payload.JobResponse{}}
+		for _, job := range jobs {
+			waitResp.Jobs = append(waitResp.Jobs, *job)
+		}
+		resp = waitResp
+	}
+
+	if err := jc.SendPayload(resp); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
 	}
 
 	// Send FIN/Half-Close Connection
-	if unixConn, ok := jc.conn.(*net.UnixConn); ok {
-		if err := unixConn.CloseWrite(); err != nil {
+	if conn, ok := jc.conn.(interface{ CloseWrite() error }); ok {
+		if err := conn.CloseWrite(); err != nil {
 			log.Printf("Warning: Failed to CloseWrite the job: %v\n", err)
 		}
 	}
-	if _, err = io.Copy(io.Discard, jc.conn); err != nil {
-		log.Printf("EOF Connection: %v\n", err)
-	}
+	<-disconnected
 	return nil
 }
 
@@ -269,15 +416,21 @@ func (d *DaemonStruct) WaitJob(jc *JobContext) error {
 func (d *DaemonStruct) StatusJob(jc *JobContext) error {
 	var req payload.JobSearchMetadata
 	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err}
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+
+	ownerUID, err := jc.ownerFilter(AUTH_LIST)
+	if err != nil {
+		return err
 	}
 
 	jobModel, err := models.NewJobModel(jc.daemon.DB, payload.JobResponse{})
 	if err != nil {
-		return &DaemonError{"Failed when initialize db model", err}
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
 	}
 
 	filter := &models.JobFilter{
+		OwnerUID:             ownerUID,
 		GeneralKeywordSearch: req.Search,
 		DBGetFilter: models.DBGetFilter{
 			Limit:    1,
@@ -286,19 +439,46 @@ func (d *DaemonStruct) StatusJob(jc *JobContext) error {
 	}
 	jobs, err := jobModel.Get(filter)
 	if err != nil {
-		return &DaemonError{"Failed when finding job", err}
+		return &DaemonError{"Failed when finding job", err, payload.ERROR_INTERNAL}
 	}
 	if sizeJob := len(jobs); sizeJob < 1 {
-		return &DaemonError{"Job not found", fmt.Errorf("len: %v", sizeJob)}
+		return &DaemonError{"Job not found", fmt.Errorf("len: %v", sizeJob), payload.ERROR_JOB_NOT_FOUND}
 	}
 
 	jobResp, err := jobs[0].ToPayload()
 	if err != nil {
-		return &DaemonError{"Failed when parsing the payload", err}
+		return &DaemonError{"Failed when parsing the payload", err, payload.ERROR_INTERNAL}
+	}
+
+	if err := fillQueuePositions(jobModel, jobResp); err != nil {
+		return &DaemonError{"Failed when computing queue positions", err, payload.ERROR_INTERNAL}
+	}
+
+	upstream, err := jobs[0].GetUpstream()
+	if err != nil {
+		return &DaemonError{"Failed when fetching job dependencies", err, payload.ERROR_INTERNAL}
+	}
+	for _, up := range upstream {
+		jobResp.Upstream = append(jobResp.Upstream, up.ToDependency())
+	}
+	downstream, err := jobs[0].GetDownstream()
+	if err != nil {
+		return &DaemonError{"Failed when fetching job dependencies", err, payload.ERROR_INTERNAL}
+	}
+	for _, down := range downstream {
+		jobResp.Downstream = append(jobResp.Downstream, down.ToDependency())
+	}
+
+	attempts, err := jobs[0].GetAttempts()
+	if err != nil {
+		return &DaemonError{"Failed when fetching job attempts", err, payload.ERROR_INTERNAL}
+	}
+	for _, attempt := range attempts {
+		jobResp.Attempts = append(jobResp.Attempts, attempt.ToPayload())
 	}
 
 	if err := jc.SendPayload(jobResp); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to send payload", err}
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
 	}
 
 	return nil
@@ -309,15 +489,21 @@ func (d *DaemonStruct) StatusJob(jc *JobContext) error {
 func (d *DaemonStruct) StopJob(jc *JobContext) error {
 	var req payload.JobSearchMetadata
 	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err}
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+
+	ownerUID, err := jc.ownerFilter(AUTH_STOP)
+	if err != nil {
+		return err
 	}
 
 	jobModel, err := models.NewJobModel(jc.daemon.DB, payload.JobResponse{})
 	if err != nil {
-		return &DaemonError{"Failed when initialize db model", err}
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
 	}
 
 	filter := &models.JobFilter{
+		OwnerUID:             ownerUID,
 		GeneralKeywordSearch: req.Search,
 		DBGetFilter: models.DBGetFilter{
 			Limit:    1,
@@ -326,50 +512,108 @@ func (d *DaemonStruct) StopJob(jc *JobContext) error {
 	}
 	jobs, err := jobModel.Get(filter)
 	if err != nil {
-		return &DaemonError{"Failed when finding job", err}
+		return &DaemonError{"Failed when finding job", err, payload.ERROR_INTERNAL}
 	}
 	if sizeJob := len(jobs); sizeJob < 1 {
-		return &DaemonError{"Job not found", fmt.Errorf("len: %v", sizeJob)}
+		return &DaemonError{"Job not found", fmt.Errorf("len: %v", sizeJob), payload.ERROR_JOB_NOT_FOUND}
 	}
 
 	job := jobs[0]
-	if err := syscall.Kill(-job.PID, syscall.SIGTERM); errors.Is(err, syscall.ESRCH) {
-		log.Printf("[WARNING] Failed when killing the pid: %v", err)
-	} else if err != nil {
+	if err := d.stopJob(job, payload.TERMINATION_STOPPED_BY_USER); err != nil {
 		return err
 	}
 
-	job.Status = int(payload.JOB_STOPPED)
-	if err := job.Update(); err != nil {
-		log.Printf("[WARNING] Failed when updating status: %+v", err)
-	}
-
 	jobPayload, err := job.ToPayload()
 	if err != nil {
-		return &DaemonError{"Failed when parsing the payload", err}
+		return &DaemonError{"Failed when parsing the payload", err, payload.ERROR_INTERNAL}
 	}
 	if err := jc.SendPayload(jobPayload); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to send payload", err}
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
 	}
 
 	return nil
 }
 
+// stopJob terminates the process group of a running job and marks the job as JOB_STOPPED
+// with the reason. Jobs that are not started yet are simply removed from the queue.
+// Finished jobs are left untouched.
+func (d *DaemonStruct) stopJob(job *models.JobModel, reason payload.TerminationReasonEnum) error {
+	status := payload.JobStatusEnum(job.Status)
+
+	// Only running jobs own a process. Finished jobs are left untouched since their PID
+	// may have been reused, and queued jobs are simply removed from the queue.
+	pid := 0
+	if status == payload.JOB_RUNNING {
+		pid = job.PID
+	}
+
+	rj, executing := d.runningJobs.Load(job.ID)
+	if executing {
+		// Prevent the daemon from starting or retrying the job after it is killed.
+		// The executor records the final status with this reason once the process exits.
+		rj.(*runningJob).requestStop(reason)
+		// The process may have started after the job was fetched, see runningJob.setProcess
+		if current := int(rj.(*runningJob).pid.Load()); current > 0 {
+			pid = current
+		}
+	}
+
+	if pid > 0 {
+		if err := syscall.Kill(-pid, syscall.SIGTERM); errors.Is(err, syscall.ESRCH) {
+			log.Printf("[WARNING] Failed when killing the pid: %v", err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if !status.IsFinished() {
+		// The update is skipped if the executor has already recorded the final status.
+		// Jobs being executed are published by the executor once their process exits.
+		if stopped, err := job.MarkJobStopped(reason); err != nil {
+			log.Printf("[WARNING] Failed when updating status: %+v", err)
+		} else if stopped && !executing {
+			d.publishJobEvent(job)
+		}
+		// Downstream jobs may be skipped now
+		d.wakeDispatcher()
+	}
+
+	return nil
+}
+
+// followPollInterval is how often a followed log is checked for new lines.
+const followPollInterval = 250 * time.Millisecond
+
 // HandleTailJobLog handles requests to tail/stream a job's log file in real-time.
 // It finds the job, locates its log file, and streams log lines to the client
 // until the connection is closed or the job completes.
 func (d *DaemonStruct) HandleTailJobLog(jc *JobContext) error {
 	var req payload.JobSearchMetadata
 	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
-		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err}
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+
+	ownerUID, err := jc.ownerFilter(AUTH_LOGS)
+	if err != nil {
+		return err
 	}
 
 	jobModel, err := models.NewJobModel(jc.daemon.DB, payload.JobResponse{})
 	if err != nil {
-		return &DaemonError{"Failed when initialize db model", err}
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
+	}
+
+	// Following ends once the job is finished. Subscribe before fetching the job,
+	// so the transition cannot be missed in between.
+	var events <-chan payload.JobEvent
+	unsubscribe := func() {}
+	defer func() { unsubscribe() }()
+	if req.Follow {
+		events, unsubscribe = d.events.subscribe()
 	}
 
 	filter := &models.JobFilter{
+		OwnerUID:             ownerUID,
 		GeneralKeywordSearch: req.Search,
 		DBGetFilter: models.DBGetFilter{
 			Limit:    1,
@@ -378,40 +622,52 @@ func (d *DaemonStruct) HandleTailJobLog(jc *JobContext) error {
 	}
 	jobs, err := jobModel.Get(filter)
 	if err != nil {
-		return &DaemonError{"Failed when finding job", err}
+		return &DaemonError{"Failed when finding job", err, payload.ERROR_INTERNAL}
 	}
 	if len(jobs) < 1 {
-		return &DaemonError{"Job not found", fmt.Errorf("no job found for search: %s", req.Search)}
+		return &DaemonError{"Job not found", fmt.Errorf("no job found for search: %s", req.Search), payload.ERROR_JOB_NOT_FOUND}
 	}
 
+	jobID := jobs[0].ID
+	finished := payload.JobStatusEnum(jobs[0].Status).IsFinished()
+	logFormat := jobs[0].LogFormat
 	jobResp, err := jobs[0].ToPayload()
 	if err != nil {
-		return &DaemonError{"Failed when parsing the payload", err}
+		return &DaemonError{"Failed when parsing the payload", err, payload.ERROR_INTERNAL}
 	}
 
 	logPath := config.GenerateJobLogPath(jc.daemon.BobbitConfig, jobResp.JobDetailMetadata)
 	if logPath == "" {
-		return &DaemonError{"Failed to generate log path", nil}
+		return &DaemonError{"Failed to generate log path", nil, payload.ERROR_INTERNAL}
 	}
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
-		return &DaemonError{"Log file not found", fmt.Errorf("path: %s", logPath)}
+	segments, err := joblog.Segments(logPath)
+	if err != nil {
+		return &DaemonError{"Failed to list log segments", err, payload.ERROR_INTERNAL}
+	}
+	if len(segments) == 0 {
+		return &DaemonError{"Log file not found", fmt.Errorf("path: %s", logPath), payload.ERROR_LOG_NOT_FOUND}
 	}
 
-	// Setup tail with follow mode
-	t, err := tail.TailFile(logPath, tail.Config{
-		Follow: req.Follow,
-		ReOpen: req.Follow,
-		Location: &tail.SeekInfo{
-			Offset: 0,
-			Whence: io.SeekStart,
-		},
-		MustExist: true,
-		Poll:      true,
-	})
+	encoder := json.NewEncoder(jc.conn)
+
+	// Rotated and compressed segments are complete, so they are read directly.
+	// Only an uncompressed active segment can be followed.
+	active := segments[len(segments)-1]
+	if !req.Follow || finished || active.Number != 0 || active.Compression != joblog.COMPRESSION_NONE {
+		return streamLogSegments(encoder, logFormat, segments)
+	}
+	if err := streamLogSegments(encoder, logFormat, segments[:len(segments)-1]); err != nil {
+		return err
+	}
+
+	// Follow the active segment, including across rotations
+	follower, err := joblog.Follow(logPath)
 	if err != nil {
-		return &DaemonError{"Failed to tail log file", err}
+		return &DaemonError{"Failed to tail log file", err, payload.ERROR_INTERNAL}
 	}
-	defer t.Stop()
+	defer follower.Close()
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
 
 	// No defer close(done) here because we might close it manually in this function
 	done := make(chan struct{})
@@ -423,24 +679,48 @@ func (d *DaemonStruct) HandleTailJobLog(jc *JobContext) error {
 		close(done)
 	}()
 
+	send := func(lines []string) bool {
+		for _, line := range lines {
+			if err := encoder.Encode(joblog.ParseLine(logFormat, line)); err != nil {
+				// Client disconnected or error writing
+				return false
+			}
+		}
+		return true
+	}
+
 	// Stream lines to client
-	encoder := json.NewEncoder(jc.conn)
 	for {
 		select {
-		case line, ok := <-t.Lines:
+		case event, ok := <-events:
 			if !ok {
-				// It should be closed
-				return nil
+				// The event bus dropped us, check the job status before subscribing again
+				unsubscribe()
+				events, unsubscribe = d.events.subscribe()
+				job := d.findJobByID(jobID)
+				finished = job == nil || payload.JobStatusEnum(job.Status).IsFinished()
+			} else {
+				finished = event.ID == jobID && event.Status.IsFinished()
 			}
-			if line.Err != nil {
-				log.Printf("Error reading log line: %v", line.Err)
-				continue
+			if finished {
+				// The log is complete now. The open segment is still readable if it is
+				// compressed in the meantime.
+				lines, err := follower.Rest()
+				send(lines)
+				if err != nil {
+					return &DaemonError{"Failed to read log file", err, payload.ERROR_INTERNAL}
+				}
+				return nil
 			}
 
-			if err := encoder.Encode(map[string]string{"line": line.Text}); err != nil {
-				// Client disconnected or error writing
+		case <-ticker.C:
+			lines, err := follower.Poll()
+			if !send(lines) {
 				return nil
 			}
+			if err != nil {
+				log.Printf("Error reading log line: %v", err)
+			}
 
 		case <-done:
 			// Connection closed by client.
@@ -449,3 +729,431 @@ func (d *DaemonStruct) HandleTailJobLog(jc *JobContext) error {
 		}
 	}
 }
+
+// streamLogSegments sends every line of the log segments to the client.
+func streamLogSegments(encoder *json.Encoder, logFormat string, segments []joblog.Segment) error {
+	reader := joblog.OpenSegments(segments)
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := encoder.Encode(joblog.ParseLine(logFormat, scanner.Text())); err != nil {
+			// Client disconnected or error writing
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &DaemonError{"Failed to read log file", err, payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
+
+// HandleSchedule handles requests to manage the recurring jobs.
+// The return depends on the action, see payload.ScheduleActionEnum.
+func (d *DaemonStruct) HandleSchedule(jc *JobContext) error {
+	var req payload.ScheduleRequestMetadata
+	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+
+	scheduleModel := &models.ScheduleModel{BaseModel: models.BaseModel{DB: d.DB}}
+
+	switch req.Action {
+	case payload.SCHEDULE_ADD:
+		if err := jc.authorizeCreate(); err != nil {
+			return err
+		}
+		return d.addSchedule(jc, req.Schedule)
+	case payload.SCHEDULE_LIST:
+		if _, err := jc.ownerFilter(AUTH_LIST); err != nil {
+			return err
+		}
+		schedules, err := scheduleModel.Get(false)
+		if err != nil {
+			return &DaemonError{"Failed when finding schedules", err, payload.ERROR_INTERNAL}
+		}
+		visible := []*models.ScheduleModel{}
+		for _, schedule := range schedules {
+			if jc.allows(AUTH_LIST, schedule.Owner()) {
+				visible = append(visible, schedule)
+			}
+		}
+		resp, err := scheduleModel.BulkToPayload(visible)
+		if err != nil {
+			return &DaemonError{"Failed when parsing the payload", err, payload.ERROR_INTERNAL}
+		}
+		if err := jc.SendPayload(resp); err != nil {
+			return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+		}
+		return nil
+	}
+
+	schedule, err := scheduleModel.Find(req.Search)
+	if err != nil {
+		return &DaemonError{"Failed when finding schedule", err, payload.ERROR_INTERNAL}
+	}
+	if req.Search == "" || schedule == nil || !jc.allows(AUTH_LIST, schedule.Owner()) {
+		return &DaemonError{"Schedule not found", fmt.Errorf("search: %q", req.Search), payload.ERROR_SCHEDULE_NOT_FOUND}
+	}
+	// Managing a schedule is allowed like stopping its jobs
+	if !jc.allows(AUTH_STOP, schedule.Owner()) {
+		return jc.forbidden("manage the schedule " + schedule.Name)
+	}
+	if req.Action == payload.SCHEDULE_TRIGGER {
+		if err := jc.authorizeCreate(); err != nil {
+			return err
+		}
+	}
+
+	var resp any
+	switch req.Action {
+	case payload.SCHEDULE_REMOVE:
+		if err := schedule.Delete(); err != nil {
+			return &DaemonError{"Failed when removing schedule", err, payload.ERROR_INTERNAL}
+		}
+	case payload.SCHEDULE_PAUSE:
+		schedule.Paused = true
+		schedule.NextRunAt = sql.NullTime{}
+		if err := schedule.SetPaused(); err != nil {
+			return &DaemonError{"Failed when pausing schedule", err, payload.ERROR_INTERNAL}
+		}
+	case payload.SCHEDULE_RESUME:
+		// The scheduler computes the next run from now, so missed runs are not caught up
+		schedule.Paused = false
+		schedule.NextRunAt = sql.NullTime{}
+		if expression, err := ParseScheduleExpression(schedule.Expression); err == nil {
+			schedule.NextRunAt = sql.NullTime{Time: expression.Next(time.Now()).UTC(), Valid: true}
+		}
+		if err := schedule.SetPaused(); err != nil {
+			return &DaemonError{"Failed when resuming schedule", err, payload.ERROR_INTERNAL}
+		}
+	case payload.SCHEDULE_TRIGGER:
+		job, err := d.fireSchedule(schedule.ID, true)
+		if err != nil {
+			return err
+		}
+		resp = job
+	default:
+		return &DaemonError{"Invalid schedule action", fmt.Errorf("action: %v", req.Action), payload.ERROR_INVALID_REQUEST}
+	}
+	d.wakeScheduler()
+
+	if resp == nil {
+		if resp, err = schedule.ToPayload(); err != nil {
+			return &DaemonError{"Failed when parsing the payload", err, payload.ERROR_INTERNAL}
+		}
+	}
+	if err := jc.SendPayload(resp); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
+
+// addSchedule validates and stores a new schedule, then responds with the created schedule.
+func (d *DaemonStruct) addSchedule(jc *JobContext, s *payload.ScheduleDetail) error {
+	if s == nil || s.Name == "" || len(s.Job.Command) < 1 {
+		return &DaemonError{"Invalid schedule: Name or Command not provided", nil, payload.ERROR_INVALID_REQUEST}
+	}
+
+	expression, err := ParseScheduleExpression(s.Expression)
+	if err != nil {
+		return &DaemonError{"Invalid schedule expression", err, payload.ERROR_INVALID_REQUEST}
+	}
+	switch s.OverlapPolicy {
+	case "", payload.OVERLAP_SKIP, payload.OVERLAP_QUEUE, payload.OVERLAP_ALLOW:
+	default:
+		return &DaemonError{"Invalid overlap policy", fmt.Errorf("unknown policy: %s", s.OverlapPolicy), payload.ERROR_INVALID_REQUEST}
+	}
+	if len(s.Job.DependsOn) > 0 {
+		return &DaemonError{"Invalid schedule", fmt.Errorf("scheduled jobs can not depend on other jobs"), payload.ERROR_INVALID_REQUEST}
+	}
+
+	hash, err := lib.GenerateRandomHash(32)
+	if err != nil {
+		return &DaemonError{"Failed to create Hash for schedule", err, payload.ERROR_INTERNAL}
+	}
+	s.ID = hash
+
+	scheduleModel, err := models.NewScheduleModel(d.DB, *s)
+	if err != nil {
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
+	}
+	scheduleModel.SetOwner(jc.Owner())
+	if !s.Paused {
+		scheduleModel.NextRunAt = sql.NullTime{Time: expression.Next(time.Now()).UTC(), Valid: true}
+	}
+	if err := scheduleModel.Save(); err != nil {
+		return &DaemonError{"Failed when creating schedule record", err, payload.ERROR_INTERNAL}
+	}
+	d.wakeScheduler()
+
+	schedule, err := scheduleModel.Find(s.ID)
+	if err != nil || schedule == nil {
+		return &DaemonError{"Failed when finding schedule", err, payload.ERROR_INTERNAL}
+	}
+	resp, err := schedule.ToPayload()
+	if err != nil {
+		return &DaemonError{"Failed when parsing the payload", err, payload.ERROR_INTERNAL}
+	}
+	if err := jc.SendPayload(resp); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
+
+// Doctor handles requests to inspect the health of the daemon.
+// The return is DoctorResponse, including the jobs reconciled on startup.
+func (d *DaemonStruct) Doctor(jc *JobContext) error {
+	var req payload.PayloadRegularMetadata
+	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+	if err := jc.authorizeAll(AUTH_LIST); err != nil {
+		return err
+	}
+
+	jobModel, err := models.NewJobModel(d.DB, payload.JobResponse{})
+	if err != nil {
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
+	}
+
+	untracked, err := d.untrackedJobs(jobModel)
+	if err != nil {
+		return &DaemonError{"Failed when finding untracked jobs", err, payload.ERROR_INTERNAL}
+	}
+	running, _ := d.countRunningJobs()
+
+	resp := payload.DoctorResponse{
+		StartedAt:   d.startedAt,
+		Reconciled:  d.reconciled,
+		RunningJobs: running,
+		Untracked:   untracked,
+	}
+	if resp.Reconciled == nil {
+		resp.Reconciled = []payload.ReconciledJob{}
+	}
+
+	if err := jc.SendPayload(resp); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
+
+// Info describes the daemon, so clients can check up front that they are compatible
+// with it. The request types handled by the daemon are given by the router.
+// The return is InfoResponse.
+func (d *DaemonStruct) Info(jc *JobContext, requests []payload.PayloadRequestEnum) error {
+	var req payload.PayloadRegularMetadata
+	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+
+	supportsJSON, err := dblib.CheckSQLiteJSONFunctions(d.DB)
+	if err != nil {
+		log.Printf("[WARNING] Failed to check SQLite JSON function support: %v", err)
+	}
+
+	c := d.live()
+	resp := payload.InfoResponse{
+		Version:            config.Version,
+		ProtocolVersion:    payload.ProtocolVersion,
+		MinProtocolVersion: payload.MinProtocolVersion,
+		Requests:           requests,
+		Features: map[payload.FeatureEnum]bool{
+			payload.FEATURE_JSON_FUNCTIONS:  supportsJSON,
+			payload.FEATURE_QUEUEING:        c.MaxConcurrentJobs > 0 || len(c.QueueLimits) > 0,
+			payload.FEATURE_SCHEDULING:      true,
+			payload.FEATURE_RETENTION:       c.RetentionMaxAge > 0 || c.RetentionFailedMaxAge > 0 || c.RetentionMaxPerName > 0,
+			payload.FEATURE_LOG_COMPRESSION: c.LogCompression != joblog.COMPRESSION_NONE,
+			payload.FEATURE_ERROR_CODES:     true,
+		},
+		StartedAt: d.startedAt,
+	}
+
+	if err := jc.SendPayload(resp); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
+
+// Prune handles requests to delete old finished jobs together with their logfiles.
+// The return is PruneResponse, including the bytes reclaimed from the logfiles.
+func (d *DaemonStruct) Prune(jc *JobContext) error {
+	var req payload.PruneRequestMetadata
+	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+	if err := jc.authorizeAll(AUTH_STOP); err != nil {
+		return err
+	}
+
+	resp, err := d.pruneJobs(req)
+	if err != nil {
+		return err
+	}
+	if !req.DryRun && len(resp.Jobs) > 0 {
+		log.Printf("Pruned %d jobs, reclaimed %s", len(resp.Jobs), lib.HumanizeBytes(resp.ReclaimedBytes))
+	}
+
+	if err := jc.SendPayload(resp); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
+
+// WatchJobs handles requests to stream the lifecycle events of jobs.
+// It replays the recent events matching the filters, then streams the new events
+// until the client disconnects if WatchRequestMetadata.Follow is set.
+func (d *DaemonStruct) WatchJobs(jc *JobContext) error {
+	var req payload.WatchRequestMetadata
+	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+	for _, t := range req.Types {
+		switch t {
+		case payload.EVENT_CREATED, payload.EVENT_STATUS_CHANGED, payload.EVENT_STARTED,
+			payload.EVENT_FINISHED, payload.EVENT_STOPPED, payload.EVENT_METADATA_UPDATED:
+		default:
+			return &DaemonError{"Invalid event type", fmt.Errorf("unknown event type: %s", t), payload.ERROR_INVALID_REQUEST}
+		}
+	}
+	if _, err := jc.ownerFilter(AUTH_LIST); err != nil {
+		return err
+	}
+	matches := func(event payload.JobEvent) bool {
+		return matchesWatch(req, event) && jc.allows(AUTH_LIST, event.Owner)
+	}
+
+	history, events, unsubscribe := d.events.subscribeWithHistory()
+	defer unsubscribe()
+
+	var replay []payload.JobEvent
+	if req.History > 0 {
+		for _, event := range history {
+			if matches(event) {
+				replay = append(replay, event)
+			}
+		}
+		replay = replay[max(0, len(replay)-req.History):]
+	}
+
+	encoder := json.NewEncoder(jc.conn)
+	if err := encoder.Encode(payload.WatchResponse{History: len(replay)}); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+	}
+	for _, event := range replay {
+		if err := encoder.Encode(event); err != nil {
+			// Client disconnected or error writing
+			return nil
+		}
+	}
+	if !req.Follow {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		// Block until the client disconnects, since no more data is expected from the client
+		buf := make([]byte, 1)
+		jc.conn.Read(buf)
+		close(done)
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return &DaemonError{"Watcher dropped", fmt.Errorf("client does not keep up with the events"), payload.ERROR_SUBSCRIBER_DROPPED}
+			}
+			if !matches(event) {
+				continue
+			}
+			if err := encoder.Encode(event); err != nil {
+				// Client disconnected or error writing
+				return nil
+			}
+
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// UpdateJobMetadata handles requests to merge new metadata into the latest job matching the search.
+// Keys with a null value are removed. The return is the updated JobResponse.
+func (d *DaemonStruct) UpdateJobMetadata(jc *JobContext) error {
+	var req payload.JobMetadataUpdateRequest
+	if err := jc.Payload.UnmarshalMetadata(&req); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to unmarshal request metadata", err, payload.ERROR_INVALID_REQUEST}
+	}
+	if req.Search == "" {
+		return &DaemonError{"Invalid metadata: Search is required", nil, payload.ERROR_INVALID_REQUEST}
+	}
+	ownerUID, err := jc.ownerFilter(AUTH_STOP)
+	if err != nil {
+		return err
+	}
+
+	jobModel, err := models.NewJobModel(jc.daemon.DB, payload.JobResponse{})
+	if err != nil {
+		return &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
+	}
+
+	jobs, err := jobModel.Get(&models.JobFilter{
+		OwnerUID:             ownerUID,
+		GeneralKeywordSearch: req.Search,
+		HideCommand:          true,
+		DBGetFilter: models.DBGetFilter{
+			Limit:    1,
+			SortDesc: true,
+		},
+	})
+	if err != nil {
+		return &DaemonError{"Failed when finding job", err, payload.ERROR_INTERNAL}
+	}
+	if len(jobs) < 1 {
+		return &DaemonError{"Job not found", fmt.Errorf("no job found for search: %s", req.Search), payload.ERROR_JOB_NOT_FOUND}
+	}
+	job := jobs[0]
+
+	metadata := map[string]any{}
+	if job.Metadata != "" {
+		if err := json.Unmarshal([]byte(job.Metadata), &metadata); err != nil {
+			return &DaemonError{"Metadata of the job is not a JSON object", err, payload.ERROR_CONFLICT}
+		}
+	}
+	for k, v := range req.Metadata {
+		if v == nil {
+			delete(metadata, k)
+			continue
+		}
+		metadata[k] = v
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return &DaemonError{"Failed when encoding metadata", err, payload.ERROR_INTERNAL}
+	}
+	job.Metadata = string(metadataBytes)
+	if err := job.UpdateMetadata(); err != nil {
+		return &DaemonError{"Failed when updating metadata", err, payload.ERROR_INTERNAL}
+	}
+	d.publishJobEventAs(payload.EVENT_METADATA_UPDATED, job)
+
+	jobPayload, err := job.ToPayload()
+	if err != nil {
+		return &DaemonError{"Failed when parsing the payload", err, payload.ERROR_INTERNAL}
+	}
+	if err := jc.SendPayload(jobPayload); err != nil {
+		return &DaemonError{"Invalid metadata: Failed to send payload", err, payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
@@ -1,18 +1,26 @@
 package daemon
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
 	"net"
 	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/mplus-oss/bobbit.go/client"
 	"github.com/mplus-oss/bobbit.go/config"
 	"github.com/mplus-oss/bobbit.go/metadata"
+	"github.com/mplus-oss/bobbit.go/metadata/models"
 	"github.com/mplus-oss/bobbit.go/payload"
 )
 
@@ -20,8 +28,35 @@ import (
 // its socket listener and configuration.
 type DaemonStruct struct {
 	SocketListener net.Listener
-	DB             *sqlx.DB
+	// TCPListener accepts remote clients with mutual TLS. It is nil if ListenTCP is not set.
+	TCPListener net.Listener
+	// HTTPListener accepts the clients of the HTTP gateway. It is nil if HTTPListen is not set.
+	HTTPListener net.Listener
+	// MetricsListener accepts the scrapers of the metrics. It is nil if MetricsListen is not set.
+	MetricsListener net.Listener
+	DB              *sqlx.DB
+	// BobbitDaemonConfig is the configuration the daemon was started with. Settings that can be
+	// reloaded must be read from Config instead.
 	config.BobbitDaemonConfig
+
+	// runningJobs tracks the jobs executed by this daemon. Key is the job ID and value is *runningJob.
+	runningJobs sync.Map
+	// dispatchWake wakes the dispatcher up when a job is queued or a slot is freed.
+	dispatchWake chan struct{}
+	// scheduleWake wakes the scheduler up when a schedule is changed.
+	scheduleWake chan struct{}
+	// scheduleMu serializes the runs of schedules, see fireSchedule.
+	scheduleMu sync.Mutex
+	// events publishes the state transitions of jobs, e.g. to wake up waiting clients.
+	events *eventBus
+	// startedAt indicates when the daemon was created.
+	startedAt time.Time
+	// reconciled holds the jobs found running when the daemon was created.
+	reconciled []payload.ReconciledJob
+	// current holds the configuration in effect, see Reload.
+	current atomic.Pointer[liveConfig]
+	// janitorWake wakes the janitor up when the retention config is reloaded.
+	janitorWake chan struct{}
 }
 
 // JobContext holds the context for a single job request handled by the daemon,
@@ -30,6 +65,10 @@ type JobContext struct {
 	conn    net.Conn
 	daemon  *DaemonStruct
 	Payload payload.JobPayload
+	// Peer holds the credentials of the client process. It is nil if they cannot be read.
+	Peer *PeerCredentials
+	// rule is what the client may do, resolved from the authorization policy.
+	rule config.AuthRule
 }
 
 // CreateDaemon initializes and starts the daemon. It checks for existing daemon
@@ -38,68 +77,283 @@ type JobContext struct {
 func CreateDaemon(c config.BobbitDaemonConfig) (*DaemonStruct, error) {
 	if socket, err := os.Stat(c.SocketPath); err == nil {
 		if socket.Mode().Type() == fs.ModeSocket {
-			return nil, &DaemonError{"Daemon is already started", fmt.Errorf("Daemon found in %v", c.SocketPath)}
+			// A socket left by a crashed daemon refuses connections, so it can be replaced
+			if conn, err := net.Dial("unix", c.SocketPath); err == nil {
+				conn.Close()
+				return nil, &DaemonError{"Daemon is already started", fmt.Errorf("Daemon found in %v", c.SocketPath), payload.ERROR_CONFLICT}
+			}
+			log.Printf("Found stale socket in %v, previous daemon did not exit cleanly.", c.SocketPath)
 		}
 	}
 
 	if err := os.MkdirAll(c.DataPath, 0755); err != nil {
-		return nil, &DaemonError{"Failed to create data directory", err}
+		return nil, &DaemonError{"Failed to create data directory", err, payload.ERROR_INTERNAL}
 	}
 
 	db, err := metadata.InitDB(c)
 	if err != nil {
-		return nil, &DaemonError{"Failed to initialize database", err}
+		return nil, &DaemonError{"Failed to initialize database", err, payload.ERROR_INTERNAL}
+	}
+
+	var authPolicy *config.AuthPolicy
+	if c.AuthPolicyPath != "" {
+		if authPolicy, err = config.LoadAuthPolicy(c.AuthPolicyPath); err != nil {
+			return nil, &DaemonError{"Failed to load auth policy", err, payload.ERROR_INTERNAL}
+		}
 	}
 
 	if err := os.RemoveAll(c.SocketPath); err != nil {
-		return nil, &DaemonError{"Failed to remove old socket path", err}
+		return nil, &DaemonError{"Failed to remove old socket path", err, payload.ERROR_INTERNAL}
+	}
+	var httpNetwork, httpAddress string
+	if c.HTTPListen != "" {
+		if httpNetwork, httpAddress, err = config.ParseAddress(c.HTTPListen); err != nil {
+			return nil, &DaemonError{"Invalid HTTP gateway address", err, payload.ERROR_INVALID_REQUEST}
+		}
+	}
+	var metricsNetwork, metricsAddress string
+	if c.MetricsListen != "" {
+		if metricsNetwork, metricsAddress, err = config.ParseAddress(c.MetricsListen); err != nil {
+			return nil, &DaemonError{"Invalid metrics address", err, payload.ERROR_INVALID_REQUEST}
+		}
+	}
+	// Clients of a plain TCP gateway cannot be identified, so anyone reaching the port could run commands
+	if httpNetwork == "tcp" && (c.TLSCertPath == "" || c.TLSKeyPath == "" || c.TLSClientCAPath == "") {
+		return nil, &DaemonError{"Invalid HTTP gateway address", fmt.Errorf("HTTP gateway over TCP requires BOBBITD_TLS_CERT, BOBBITD_TLS_KEY and BOBBITD_TLS_CLIENT_CA"), payload.ERROR_INVALID_REQUEST}
+	}
+	// The metrics are authorized like on the gateway, see AuthorizeMetrics
+	if metricsNetwork == "tcp" && (c.TLSCertPath == "" || c.TLSKeyPath == "" || c.TLSClientCAPath == "") {
+		return nil, &DaemonError{"Invalid metrics address", fmt.Errorf("metrics over TCP require BOBBITD_TLS_CERT, BOBBITD_TLS_KEY and BOBBITD_TLS_CLIENT_CA"), payload.ERROR_INVALID_REQUEST}
+	}
+	var tlsConfig *tls.Config
+	if c.ListenTCP != "" || httpNetwork == "tcp" || metricsNetwork == "tcp" {
+		if tlsConfig, err = config.LoadTLSConfig(c.TLSCertPath, c.TLSKeyPath, c.TLSClientCAPath, true); err != nil {
+			return nil, &DaemonError{"Failed to load TLS configuration", err, payload.ERROR_INTERNAL}
+		}
+	}
+
+	listener, err := listenSocket(c.SocketPath, c)
+	if err != nil {
+		return nil, &DaemonError{"Failed to listen in socket path", err, payload.ERROR_INTERNAL}
+	}
+	var tcpListener net.Listener
+	if c.ListenTCP != "" {
+		if tcpListener, err = tls.Listen("tcp", c.ListenTCP, tlsConfig); err != nil {
+			listener.Close()
+			return nil, &DaemonError{"Failed to listen in TCP address", err, payload.ERROR_INTERNAL}
+		}
+	}
+	var httpListener net.Listener
+	switch {
+	case httpNetwork == "unix":
+		if err = os.RemoveAll(httpAddress); err == nil {
+			httpListener, err = listenSocket(httpAddress, c)
+		}
+	case httpNetwork == "tcp":
+		httpListener, err = tls.Listen("tcp", httpAddress, tlsConfig)
 	}
-	listener, err := net.Listen("unix", c.SocketPath)
 	if err != nil {
-		return nil, &DaemonError{"Failed to listen in socket path", err}
+		listener.Close()
+		if tcpListener != nil {
+			tcpListener.Close()
+		}
+		return nil, &DaemonError{"Failed to listen in HTTP gateway address", err, payload.ERROR_INTERNAL}
+	}
+	var metricsListener net.Listener
+	if c.MetricsListen != "" {
+		if metricsNetwork == "unix" {
+			if err = os.RemoveAll(metricsAddress); err == nil {
+				metricsListener, err = listenSocket(metricsAddress, c)
+			}
+		} else {
+			metricsListener, err = tls.Listen(metricsNetwork, metricsAddress, tlsConfig)
+		}
+		if err != nil {
+			for _, l := range []net.Listener{listener, tcpListener, httpListener} {
+				if l != nil {
+					l.Close()
+				}
+			}
+			return nil, &DaemonError{"Failed to listen in metrics address", err, payload.ERROR_INTERNAL}
+		}
 	}
 
-	return &DaemonStruct{
+	d := &DaemonStruct{
 		SocketListener:     listener,
+		TCPListener:        tcpListener,
+		HTTPListener:       httpListener,
+		MetricsListener:    metricsListener,
 		DB:                 db,
 		BobbitDaemonConfig: c,
-	}, nil
+		dispatchWake:       make(chan struct{}, 1),
+		scheduleWake:       make(chan struct{}, 1),
+		events:             newEventBus(),
+		janitorWake:        make(chan struct{}, 1),
+		startedAt:          time.Now(),
+	}
+	d.current.Store(&liveConfig{BobbitDaemonConfig: c, authPolicy: authPolicy})
+
+	d.registerMetrics()
+
+	// Jobs left running by a crashed daemon must be adopted or marked as lost
+	// before the dispatcher starts new jobs.
+	if err := d.reconcileRunningJobs(); err != nil {
+		log.Printf("[WARNING] Failed to reconcile running jobs: %v", err)
+	}
+
+	return d, nil
+}
+
+// listenSocket creates a Unix socket in the path with the configured mode and owner. Until they
+// are applied, the socket is only accessible by the daemon user.
+func listenSocket(path string, c config.BobbitDaemonConfig) (net.Listener, error) {
+	if c.SocketMode == 0 && c.SocketOwner == "" {
+		return net.Listen("unix", path)
+	}
+
+	oldUmask := syscall.Umask(0177)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(oldUmask)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.SocketOwner != "" {
+		uid, gid, err := lookupOwner(c.SocketOwner)
+		if err == nil {
+			err = os.Chown(path, uid, gid)
+		}
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("failed to set socket owner %s: %w", c.SocketOwner, err)
+		}
+	}
+
+	mode := c.SocketMode
+	if mode == 0 {
+		mode = 0666 &^ os.FileMode(oldUmask)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket mode %v: %w", mode, err)
+	}
+
+	return listener, nil
+}
+
+// lookupOwner resolves an owner in `user:group` format, where both parts are optional and can
+// be a name or an ID. Missing parts are returned as -1, so os.Chown leaves them unchanged.
+func lookupOwner(owner string) (uid int, gid int, err error) {
+	userName, groupName, _ := strings.Cut(owner, ":")
+
+	uid, gid = -1, -1
+	if userName != "" {
+		if uid, err = strconv.Atoi(userName); err != nil {
+			u, err := user.Lookup(userName)
+			if err != nil {
+				return 0, 0, err
+			}
+			uid, _ = strconv.Atoi(u.Uid)
+		}
+	}
+	if groupName != "" {
+		if gid, err = strconv.Atoi(groupName); err != nil {
+			g, err := user.LookupGroup(groupName)
+			if err != nil {
+				return 0, 0, err
+			}
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	}
+
+	return uid, gid, nil
 }
 
 // NewJobContext creates and returns a new JobContext for a given network connection.
-// This context is used to manage a single job request.
+// This context is used to manage a single job request. The credentials of the client
+// are read from the connection to resolve what it may do.
 func (d *DaemonStruct) NewJobContext(conn net.Conn) *JobContext {
+	return d.NewJobContextAs(conn, d.Identify(conn))
+}
+
+// Identify reads the credentials of the client on the other side of the connection,
+// see PeerCredentials. It returns nil if they cannot be read.
+func (d *DaemonStruct) Identify(conn net.Conn) *PeerCredentials {
+	var peer *PeerCredentials
+	var err error
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		peer, err = readCertificateCredentials(tlsConn)
+	} else {
+		peer, err = readPeerCredentials(conn)
+	}
+	if err != nil && d.live().authPolicy != nil {
+		log.Printf("[WARNING] Failed to read peer credentials: %v", err)
+	}
+	return peer
+}
+
+// NewJobContextAs creates a JobContext for a client identified on another connection,
+// e.g. a request forwarded by the HTTP gateway. The peer may be nil if the client is unknown.
+func (d *DaemonStruct) NewJobContextAs(conn net.Conn, peer *PeerCredentials) *JobContext {
 	return &JobContext{
 		conn:   conn,
 		daemon: d,
+		Peer:   peer,
+		rule:   d.authRule(peer),
 	}
 }
 
+// shutdownKillWait is how long the daemon waits for the executors of the jobs killed on
+// shutdown to record their final status.
+const shutdownKillWait = 2 * time.Second
+
 // CleanupDaemon listens for an OS signal and performs cleanup operations
-// before exiting. It removes the daemon's socket file.
+// before exiting. It stops the running jobs, killing those still running after
+// the kill grace period, and removes the daemon's socket file.
 func (d *DaemonStruct) CleanupDaemon(sigChan <-chan os.Signal) {
 	sig := <-sigChan
 	log.Printf("Received signal %v. Starting cleanup daemon...", sig)
 
-	log.Printf("Bootstrapping new client for daemon.")
-	cli := client.New(config.NewClient())
-
-	// List running app and force it to exit
-	req, err := cli.List(payload.JobSearchMetadata{
-		ActiveOnly: true,
-	})
+	// Stop running jobs and force it to exit. Queued jobs are kept for the next daemon.
+	jobModel, err := models.NewJobModel(d.DB, payload.JobResponse{})
 	if err != nil {
+		log.Printf("Failed when initialize db model: %v", err)
+	} else if jobs, err := jobModel.GetRunningJobs(); err != nil {
 		log.Printf("Failed to list the active jobs: %v", err)
 	} else {
-		for _, job := range req {
+		for _, job := range jobs {
 			log.Printf("Stopping running job: %v", job.ID)
-			if _, err := cli.Stop(job.ID); err != nil {
+			if err := d.stopJob(job, payload.TERMINATION_DAEMON_SHUTDOWN); err != nil {
 				log.Printf("Failed when stopping the job [%v]: %v", job.ID, err)
-				continue
 			}
 		}
 	}
 
+	// Give the executors a chance to record the final status of the stopped jobs
+	if !d.waitRunningJobs(d.live().KillGracePeriod) {
+		// Kill the jobs ignoring SIGTERM, so no process outlives the daemon
+		d.runningJobs.Range(func(key, value any) bool {
+			if pid := int(value.(*runningJob).pid.Load()); pid > 0 {
+				log.Printf("Job %v is still running after %v, sending SIGKILL", key, d.live().KillGracePeriod)
+				if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+					log.Printf("[WARNING] Failed to send SIGKILL to process group %d: %v", pid, err)
+				}
+			}
+			return true
+		})
+		d.waitRunningJobs(shutdownKillWait)
+	}
+
+	if d.TCPListener != nil {
+		d.TCPListener.Close()
+	}
+	// Closing a Unix listener also removes its socket file
+	for _, l := range []net.Listener{d.HTTPListener, d.MetricsListener} {
+		if l != nil {
+			l.Close()
+		}
+	}
+
 	log.Println("Removing socket file...")
 	if err := os.Remove(d.SocketPath); err != nil && !os.IsNotExist(err) {
 		log.Printf("Warning: Failed to remove socket: %v", err)
@@ -109,13 +363,26 @@ func (d *DaemonStruct) CleanupDaemon(sigChan <-chan os.Signal) {
 	os.Exit(0)
 }
 
+// waitRunningJobs waits until no job is running anymore, at most for timeout.
+// It returns false if jobs are still running.
+func (d *DaemonStruct) waitRunningJobs(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for running, _ := d.countRunningJobs(); running > 0; running, _ = d.countRunningJobs() {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
+
 // GetPayload reads and decodes a JobPayload from the JobContext's network connection.
 // It populates the Payload field of the JobContext and returns an error if decoding fails.
 // If the payload's timestamp is zero, it defaults to the current time.
 func (jc *JobContext) GetPayload() error {
 	var p payload.JobPayload
 	if err := json.NewDecoder(jc.conn).Decode(&p); err != nil {
-		return &DaemonError{"Failed to decode payload.", err}
+		return &DaemonError{"Failed to decode payload.", err, payload.ERROR_INVALID_REQUEST}
 	}
 	if p.Timestamp.IsZero() {
 		p.Timestamp = time.Now()
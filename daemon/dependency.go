@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/internal/joblog"
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// resolveDependencies resolves the job references (ID or name) into job IDs.
+// Names resolve to the latest job with that name. It rejects unknown jobs and
+// dependency cycles.
+func (d *DaemonStruct) resolveDependencies(jobModel *models.JobModel, jobID string, refs []string) ([]string, error) {
+	ids := []string{}
+	for _, ref := range refs {
+		jobs, err := jobModel.Get(&models.JobFilter{
+			GeneralKeywordSearch: ref,
+			HideCommand:          true,
+			DBGetFilter: models.DBGetFilter{
+				Limit:    1,
+				SortDesc: true,
+			},
+		})
+		if err != nil {
+			return nil, &DaemonPayloadError{"Failed when finding dependency", jobID, err, payload.ERROR_INTERNAL}
+		}
+		if len(jobs) < 1 {
+			return nil, &DaemonPayloadError{"Dependency not found", jobID, fmt.Errorf("no job found for search: %s", ref), payload.ERROR_INVALID_REQUEST}
+		}
+
+		if !slices.Contains(ids, jobs[0].ID) {
+			ids = append(ids, jobs[0].ID)
+		}
+	}
+
+	if err := detectDependencyCycle(jobModel, jobID, ids); err != nil {
+		return nil, &DaemonPayloadError{"Dependency cycle detected", jobID, err, payload.ERROR_INVALID_REQUEST}
+	}
+
+	return ids, nil
+}
+
+// detectDependencyCycle walks the upstream graph from every dependency and
+// returns an error if jobID can be reached, which would form a cycle.
+func detectDependencyCycle(jobModel *models.JobModel, jobID string, dependsOnIDs []string) error {
+	visited := map[string]bool{}
+	stack := slices.Clone(dependsOnIDs)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if id == jobID {
+			return fmt.Errorf("job %s depends on itself", jobID)
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		upstream, err := jobModel.GetDependencyIDs(id)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, upstream...)
+	}
+
+	return nil
+}
+
+// resolvePendingJobs moves pending jobs whose dependencies are satisfied to the queue,
+// and skips pending jobs whose dependency condition can no longer be met. Skips cascade
+// to the downstream jobs within the same call.
+func (d *DaemonStruct) resolvePendingJobs(jobModel *models.JobModel) {
+	for {
+		pending, err := jobModel.GetPendingJobs()
+		if err != nil {
+			log.Printf("[WARNING] Failed to fetch pending jobs: %v", err)
+			return
+		}
+
+		skipped := false
+		for _, job := range pending {
+			upstream, err := job.GetUpstream()
+			if err != nil {
+				log.Printf("[WARNING] Failed to fetch dependencies of job %s: %v", job.ID, err)
+				continue
+			}
+
+			condition := payload.DependencyConditionEnum(job.DependencyCondition)
+			ready := true
+			var blocker *models.JobModel
+			for _, up := range upstream {
+				status := payload.JobStatusEnum(up.Status)
+				if !status.IsFinished() {
+					ready = false
+					continue
+				}
+				if !condition.IsSatisfiedBy(status) {
+					blocker = up
+					break
+				}
+			}
+
+			if blocker != nil {
+				if ok, err := job.TransitionStatus(payload.JOB_PENDING, payload.JOB_SKIPPED); err != nil {
+					log.Printf("[WARNING] %v", err)
+				} else if ok {
+					skipped = true
+					d.publishJobEvent(job)
+					log.Printf("Job %s skipped: dependency %s finished with status %s",
+						job.ID, blocker.ID, payload.ParseJobStatus(payload.JobStatusEnum(blocker.Status)))
+					d.appendJobLog(job, "===== [bobbit] Skipped: dependency %s (%s) finished with status \"%s\" =====\n",
+						blocker.ID, blocker.JobName, payload.ParseJobStatus(payload.JobStatusEnum(blocker.Status)))
+				}
+				continue
+			}
+
+			if ready {
+				if ok, err := job.TransitionStatus(payload.JOB_PENDING, payload.JOB_QUEUED); err != nil {
+					log.Printf("[WARNING] %v", err)
+				} else if ok {
+					d.publishJobEvent(job)
+				}
+			}
+		}
+
+		if !skipped {
+			return
+		}
+	}
+}
+
+// appendJobLog writes a daemon message into the logfile of the job.
+func (d *DaemonStruct) appendJobLog(job *models.JobModel, format string, v ...any) {
+	logPath := config.GenerateJobLogPath(d.BobbitConfig, payload.JobDetailMetadata{
+		ID:        job.ID,
+		CreatedAt: job.CreatedAt,
+	})
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("[WARNING] Failed to open logfile of job %s: %v", job.ID, err)
+		return
+	}
+	defer f.Close()
+
+	if job.LogFormat == joblog.FORMAT_FRAMED {
+		joblog.NewWriter(f).Printf(format, v...)
+		return
+	}
+	fmt.Fprintf(f, format, v...)
+}
@@ -0,0 +1,160 @@
+package daemon
+
+import (
+	"log"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// RunDispatcher starts queued jobs in submission order while respecting
+// BobbitDaemonConfig.MaxConcurrentJobs and BobbitDaemonConfig.QueueLimits.
+//
+// The queue is persisted in the database, so jobs queued before a daemon restart
+// are picked up when the dispatcher starts. This function blocks forever.
+func (d *DaemonStruct) RunDispatcher() {
+	jobModel, err := models.NewJobModel(d.DB, payload.JobResponse{})
+	if err != nil {
+		log.Printf("[WARNING] Failed when initialize db model for dispatcher: %v", err)
+		return
+	}
+
+	// Jobs that were claimed but never started belong to the previous daemon
+	if requeued, err := jobModel.RequeueClaimedJobs(); err != nil {
+		log.Printf("[WARNING] Failed to requeue unstarted jobs: %v", err)
+	} else if requeued > 0 {
+		log.Printf("Requeued %d unstarted job(s) from previous daemon.", requeued)
+	}
+
+	for {
+		next := d.dispatch(jobModel)
+
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timerC = timer.C
+		}
+
+		select {
+		case <-timerC:
+		case <-d.dispatchWake:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// wakeDispatcher asks the dispatcher to look for jobs that can be started.
+// It never blocks; multiple wake-ups before the dispatcher runs are coalesced.
+func (d *DaemonStruct) wakeDispatcher() {
+	select {
+	case d.dispatchWake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch promotes due scheduled jobs, resolves pending jobs and starts as many queued jobs
+// as the concurrency limits allow. It returns the time the next scheduled job is due,
+// or zero time if there is none.
+func (d *DaemonStruct) dispatch(jobModel *models.JobModel) time.Time {
+	next := d.promoteScheduledJobs(jobModel)
+	d.resolvePendingJobs(jobModel)
+
+	queued, err := jobModel.GetQueuedJobs()
+	if err != nil {
+		log.Printf("[WARNING] Failed to fetch queued jobs: %v", err)
+		return next
+	}
+
+	c := d.live()
+	running, runningPerQueue := d.countRunningJobs()
+	for _, job := range queued {
+		if c.MaxConcurrentJobs > 0 && running >= c.MaxConcurrentJobs {
+			break
+		}
+		// Other queues may still have free slots, so keep looking
+		if limit := c.QueueLimits[job.Queue]; limit > 0 && runningPerQueue[job.Queue] >= limit {
+			continue
+		}
+
+		claimed, err := job.ClaimQueuedJob()
+		if err != nil {
+			log.Printf("[WARNING] %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		rj := newRunningJob(job.Queue)
+		d.runningJobs.Store(job.ID, rj)
+		running++
+		runningPerQueue[job.Queue]++
+
+		go d.runJob(job, rj)
+	}
+
+	return next
+}
+
+// promoteScheduledJobs moves the scheduled jobs that are due to the queue, or to pending
+// if they have dependencies. It returns the time the next scheduled job is due.
+func (d *DaemonStruct) promoteScheduledJobs(jobModel *models.JobModel) time.Time {
+	scheduled, err := jobModel.GetScheduledJobs()
+	if err != nil {
+		log.Printf("[WARNING] Failed to fetch scheduled jobs: %v", err)
+		return time.Now().Add(time.Minute)
+	}
+
+	now := time.Now()
+	for _, job := range scheduled {
+		if job.NotBefore.Valid && job.NotBefore.Time.After(now) {
+			// Jobs are ordered by NotBefore, so the rest are not due either
+			return job.NotBefore.Time
+		}
+
+		dependsOn, err := job.GetDependencyIDs(job.ID)
+		if err != nil {
+			log.Printf("[WARNING] Failed to fetch dependencies of job %s: %v", job.ID, err)
+			continue
+		}
+		to := payload.JOB_QUEUED
+		if len(dependsOn) > 0 {
+			to = payload.JOB_PENDING
+		}
+
+		if ok, err := job.TransitionStatus(payload.JOB_SCHEDULED, to); err != nil {
+			log.Printf("[WARNING] %v", err)
+		} else if ok {
+			d.publishJobEvent(job)
+		}
+	}
+
+	return time.Time{}
+}
+
+// runJob executes the job and frees its slot once it is finished.
+func (d *DaemonStruct) runJob(job *models.JobModel, rj *runningJob) {
+	defer d.wakeDispatcher()
+	defer d.runningJobs.Delete(job.ID)
+
+	if err := d.executeJob(job, rj); err != nil {
+		log.Printf("Job %s: %v", job.ID, err)
+	}
+}
+
+// countRunningJobs returns the number of jobs executed by the daemon, in total and per queue.
+func (d *DaemonStruct) countRunningJobs() (int, map[string]int) {
+	total := 0
+	perQueue := map[string]int{}
+	d.runningJobs.Range(func(_, value any) bool {
+		total++
+		perQueue[value.(*runningJob).queue]++
+		return true
+	})
+	return total, perQueue
+}
@@ -1,13 +1,18 @@
 package daemon
 
 import (
+	"errors"
 	"fmt"
 	"log"
+
+	"github.com/mplus-oss/bobbit.go/payload"
 )
 
 type DaemonError struct {
 	Message     string
 	ParentError error
+	// Code is sent to the client together with the message, see payload.JobErrorResponse.
+	Code payload.ErrorCodeEnum
 }
 
 func (d *DaemonError) Error() string {
@@ -18,6 +23,8 @@ type DaemonPayloadError struct {
 	Message     string
 	JobID       string
 	ParentError error
+	// Code is sent to the client together with the message, see payload.JobErrorResponse.
+	Code payload.ErrorCodeEnum
 }
 
 func (d *DaemonPayloadError) Error() string {
@@ -27,3 +34,31 @@ func (d *DaemonPayloadError) Error() string {
 func (d *DaemonPayloadError) Warning() {
 	log.Printf("WARNING: [%s] %s: %v", d.JobID, d.Message, d.ParentError)
 }
+
+// ErrorResponse builds the response sent to the client when a handler fails.
+// Errors other than DaemonError and DaemonPayloadError are reported as internal errors.
+func ErrorResponse(err error) payload.JobErrorResponse {
+	resp := payload.JobErrorResponse{Error: err.Error(), Code: payload.ERROR_INTERNAL}
+
+	var parentError error
+	var daemonErr *DaemonError
+	var payloadErr *DaemonPayloadError
+	switch {
+	case errors.As(err, &daemonErr):
+		resp.Code = daemonErr.Code
+		parentError = daemonErr.ParentError
+	case errors.As(err, &payloadErr):
+		resp.Code = payloadErr.Code
+		resp.JobID = payloadErr.JobID
+		parentError = payloadErr.ParentError
+	}
+
+	if resp.Code == "" {
+		resp.Code = payload.ERROR_INTERNAL
+	}
+	if parentError != nil {
+		resp.Details = parentError.Error()
+	}
+
+	return resp
+}
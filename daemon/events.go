@@ -0,0 +1,169 @@
+package daemon
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/internal/lib"
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+const (
+	// eventBufferSize is the number of events buffered per subscriber.
+	eventBufferSize = 64
+	// eventHistorySize is the number of recent events kept for replay.
+	eventHistorySize = 256
+)
+
+// eventBus fans job events out to every subscriber within the daemon, and keeps the
+// recent events in a ring buffer so they can be replayed to new subscribers.
+//
+// Publishing never blocks. A subscriber that does not keep up is dropped and its channel
+// is closed, so it has to subscribe again and check the current state in the database.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan payload.JobEvent]struct{}
+	history     []payload.JobEvent
+	// next is the index of history where the next event is written once it is full.
+	next int
+}
+
+// newEventBus creates an event bus without subscribers.
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: map[chan payload.JobEvent]struct{}{}}
+}
+
+// subscribe registers a new subscriber. The returned function unsubscribes it and must be
+// called once the subscriber is done.
+func (b *eventBus) subscribe() (<-chan payload.JobEvent, func()) {
+	_, ch, unsubscribe := b.subscribeWithHistory()
+	return ch, unsubscribe
+}
+
+// subscribeWithHistory registers a new subscriber like subscribe, and returns the recent
+// events from the oldest one. No event is missed or duplicated between both.
+func (b *eventBus) subscribeWithHistory() ([]payload.JobEvent, <-chan payload.JobEvent, func()) {
+	ch := make(chan payload.JobEvent, eventBufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	history := append(append([]payload.JobEvent{}, b.history[b.next:]...), b.history[:b.next]...)
+	b.mu.Unlock()
+
+	return history, ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends the event to every subscriber.
+func (b *eventBus) publish(event payload.JobEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.history) < eventHistorySize {
+		b.history = append(b.history, event)
+	} else {
+		b.history[b.next] = event
+		b.next = (b.next + 1) % eventHistorySize
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publishJobEvent publishes the status transition of the job.
+// The event type is derived from the new status.
+func (d *DaemonStruct) publishJobEvent(job *models.JobModel) {
+	status := payload.JobStatusEnum(job.Status)
+
+	eventType := payload.EVENT_STATUS_CHANGED
+	switch {
+	case status == payload.JOB_RUNNING:
+		eventType = payload.EVENT_STARTED
+	case status == payload.JOB_STOPPED:
+		eventType = payload.EVENT_STOPPED
+	case status.IsFinished():
+		eventType = payload.EVENT_FINISHED
+	}
+	d.publishJobEventAs(eventType, job)
+}
+
+// publishJobEventAs publishes the current state of the job as the given event type.
+func (d *DaemonStruct) publishJobEventAs(eventType payload.JobEventTypeEnum, job *models.JobModel) {
+	status := payload.JobStatusEnum(job.Status)
+	event := payload.JobEvent{
+		Type:              eventType,
+		ID:                job.ID,
+		JobName:           job.JobName,
+		Status:            status,
+		ExitCode:          job.ExitCode,
+		TerminationReason: payload.TerminationReasonEnum(job.TerminationReason),
+		Signal:            job.Signal,
+		Owner:             job.Owner(),
+		Timestamp:         time.Now(),
+	}
+	if job.Metadata != "" {
+		var metadata payload.PayloadRegularMetadata
+		if err := json.Unmarshal([]byte(job.Metadata), &metadata); err == nil {
+			event.Metadata = metadata
+		}
+	}
+
+	observeJobEvent(event)
+	d.events.publish(event)
+}
+
+// matchesWatch reports whether the event matches every filter of the watch request.
+func matchesWatch(req payload.WatchRequestMetadata, event payload.JobEvent) bool {
+	if req.IDPrefix != "" && !strings.HasPrefix(event.ID, req.IDPrefix) {
+		return false
+	}
+	if req.JobName != "" && !lib.MatchLike(req.JobName, event.JobName) {
+		return false
+	}
+	if len(req.Types) > 0 && !slices.Contains(req.Types, event.Type) {
+		return false
+	}
+
+	if len(req.MetadataFilter) == 0 {
+		return true
+	}
+	metadata, ok := event.Metadata.(map[string]any)
+	if !ok {
+		return false
+	}
+	for k, pattern := range req.MetadataFilter {
+		value, found := metadata[k]
+		if !found || !lib.MatchLike(pattern, metadataString(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// metadataString formats a metadata value the way SQLite compares it with LIKE.
+func metadataString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
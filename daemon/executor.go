@@ -0,0 +1,346 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/internal/joblog"
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// runningJob holds the runtime state of a job executed by the daemon.
+type runningJob struct {
+	queue   string
+	stopped atomic.Bool
+	// stopReason is set once before stopped is stored, so it is safe to read after stopped is true.
+	stopReason payload.TerminationReasonEnum
+	stop       chan struct{}
+	stopOnce   sync.Once
+	// pid is the process of the current attempt, 0 while no process runs
+	pid atomic.Int64
+}
+
+// spoolDrainInterval is how often the output spooled by a running job is framed into its logfile.
+const spoolDrainInterval = 250 * time.Millisecond
+
+// attemptResult holds the outcome of a single execution of the job command.
+type attemptResult struct {
+	exitCode int
+	signal   int
+	reason   payload.TerminationReasonEnum
+}
+
+func newRunningJob(queue string) *runningJob {
+	return &runningJob{queue: queue, stop: make(chan struct{})}
+}
+
+// requestStop marks the job as stopped so no further attempt is executed.
+// Only the first reason is kept.
+func (r *runningJob) requestStop(reason payload.TerminationReasonEnum) {
+	r.stopOnce.Do(func() {
+		r.stopReason = reason
+		r.stopped.Store(true)
+		close(r.stop)
+	})
+}
+
+// setProcess records the process of the current attempt, and terminates it if the job was
+// stopped in the meantime. Either stopJob sees the process, or the process sees the stop request.
+func (r *runningJob) setProcess(pid int) {
+	r.pid.Store(int64(pid))
+	if r.stopped.Load() {
+		if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			log.Printf("[WARNING] Failed when killing the pid: %v", err)
+		}
+	}
+}
+
+// executeJob runs the job command until it succeeds, the retry policy is exhausted,
+// or the job is stopped. Each attempt is appended to the same logfile and recorded in
+// the job_attempts table. The job stays JOB_RUNNING between attempts.
+//
+// The job must be registered in DaemonStruct.runningJobs before calling this function.
+func (d *DaemonStruct) executeJob(job *models.JobModel, rj *runningJob) error {
+	jobResp, err := job.ToPayload()
+	if err != nil {
+		job.ExitCode = 127
+		job.TerminationReason = string(payload.TERMINATION_START_FAILED)
+		if markErr := job.MarkJobFinished(); markErr != nil {
+			log.Printf("[WARNING] Failed when updating status: %+v", markErr)
+		} else {
+			d.publishJobEvent(job)
+		}
+		return &DaemonPayloadError{"Failed when parsing the payload", job.ID, err, payload.ERROR_INTERNAL}
+	}
+	p := jobResp.JobDetailMetadata
+	metadataStr := job.Metadata
+
+	logPath := config.GenerateJobLogPath(d.BobbitConfig, p)
+	logWriter, err := joblog.CreateWriter(logPath, d.logOptions(p))
+	if err != nil {
+		job.ExitCode = 127
+		job.TerminationReason = string(payload.TERMINATION_START_FAILED)
+		if markErr := job.MarkJobFinished(); markErr != nil {
+			log.Printf("[WARNING] Failed when updating status: %+v", markErr)
+		} else {
+			d.publishJobEvent(job)
+		}
+		return &DaemonPayloadError{"Failed to open logfile", p.ID, err, payload.ERROR_INTERNAL}
+	}
+	defer func() {
+		if err := logWriter.Close(); err != nil {
+			log.Printf("[WARNING] Failed to close logfile of job %s: %v", p.ID, err)
+		}
+		if err := joblog.Compress(logPath, d.live().LogCompression); err != nil {
+			log.Printf("[WARNING] %v", err)
+		}
+	}()
+
+	// Jobs queued before an upgrade have an empty logfile, so they can be framed as well
+	job.LogFormat = joblog.FORMAT_FRAMED
+
+	maxAttempts := 1
+	if p.Retry != nil && p.Retry.MaxAttempts > 1 {
+		maxAttempts = p.Retry.MaxAttempts
+	}
+
+	var result attemptResult
+	executionStarted := time.Now()
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// The job was stopped before its first attempt, StopJob already updated its status
+		if attempt == 1 && rj.stopped.Load() {
+			job.Status = int(payload.JOB_STOPPED)
+			job.TerminationReason = string(rj.stopReason)
+			d.publishJobEvent(job)
+			return nil
+		}
+
+		if p.Retry != nil {
+			logWriter.Printf("===== [bobbit] Attempt %d/%d started at %s =====",
+				attempt, maxAttempts, time.Now().Format(time.RFC3339))
+		}
+
+		started := time.Now()
+		var err error
+		result, err = d.runAttempt(job, rj, p, attempt, logPath, logWriter, metadataStr)
+		if err != nil {
+			log.Printf("[WARNING] Failed when starting job %s: %v", p.ID, err)
+			logWriter.Printf("===== [bobbit] Failed to start the command: %v =====", err)
+		}
+
+		if p.Retry != nil {
+			logWriter.Printf("===== [bobbit] Attempt %d/%d exited with code %d after %v =====",
+				attempt, maxAttempts, result.exitCode, time.Since(started).Round(time.Millisecond))
+		}
+
+		if rj.stopped.Load() || attempt == maxAttempts || !p.Retry.ShouldRetry(result.exitCode, result.reason) {
+			break
+		}
+
+		delay := p.Retry.BackoffDelay(attempt)
+		log.Printf("Job %s attempt %d/%d exited with code %d, retrying in %v", p.ID, attempt, maxAttempts, result.exitCode, delay)
+		select {
+		case <-time.After(delay):
+		case <-rj.stop:
+		}
+		if rj.stopped.Load() {
+			break
+		}
+	}
+
+	// A stop request takes precedence, unless the process was already terminated by its timeout
+	if rj.stopped.Load() && result.reason != payload.TERMINATION_TIMEOUT {
+		result.reason = rj.stopReason
+	}
+
+	job.ExitCode = result.exitCode
+	job.Signal = result.signal
+	job.TerminationReason = string(result.reason)
+	if err := job.MarkJobFinished(); err != nil {
+		return &DaemonPayloadError{"Failed to update job", p.ID, err, payload.ERROR_INTERNAL}
+	}
+	jobDuration.Observe(time.Since(executionStarted).Seconds(), p.JobName)
+	d.publishJobEvent(job)
+
+	switch result.reason {
+	case payload.TERMINATION_TIMEOUT:
+		return &DaemonPayloadError{"Timed out", p.ID, fmt.Errorf("timeout: %v", p.Timeout), payload.ERROR_INTERNAL}
+	case payload.TERMINATION_SIGNALED:
+		return &DaemonPayloadError{"Killed by signal", p.ID, fmt.Errorf("signal: %v", syscall.Signal(result.signal)), payload.ERROR_INTERNAL}
+	}
+
+	if job.ExitCode > 0 {
+		return &DaemonPayloadError{"Exit with code", p.ID, fmt.Errorf("code: %d", job.ExitCode), payload.ERROR_INTERNAL}
+	}
+
+	return nil
+}
+
+// logOptions returns the log size limit of the job, falling back to the daemon defaults.
+func (d *DaemonStruct) logOptions(p payload.JobDetailMetadata) joblog.Options {
+	c := d.live()
+	opts := joblog.Options{
+		MaxSize:     c.LogMaxSize,
+		Policy:      c.LogPolicy,
+		MaxSegments: c.LogMaxSegments,
+	}
+	if p.LogMaxSize > 0 {
+		opts.MaxSize = p.LogMaxSize
+	}
+	if p.LogPolicy != "" {
+		opts.Policy = p.LogPolicy
+	}
+	return opts
+}
+
+// runAttempt executes the job command once and waits until the process exits.
+// The process is terminated if it exceeds the job timeout.
+func (d *DaemonStruct) runAttempt(job *models.JobModel, rj *runningJob, p payload.JobDetailMetadata, attempt int, logPath string, logWriter *joblog.Writer, metadataStr string) (attemptResult, error) {
+	// Prep the output. The process writes to spool files instead of pipes, so it keeps its
+	// output and does not get SIGPIPE if the daemon stops. Each stream is framed separately.
+	stdout, stderr, err := joblog.CreateSpool(logPath)
+	if err != nil {
+		return attemptResult{exitCode: 127, reason: payload.TERMINATION_START_FAILED}, err
+	}
+
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Dir = p.WorkDir
+
+	// Make it as a different group
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	// Add job-specific environment variables
+	cmd.Env = append(
+		buildJobEnv(p),
+		fmt.Sprintf("JOB_ID=%s", p.ID),
+		fmt.Sprintf("JOB_NAME=%s", p.JobName),
+		fmt.Sprintf("JOB_METADATA=%s", metadataStr),
+		fmt.Sprintf("JOB_ATTEMPT=%d", attempt),
+	)
+
+	// The attempt is recorded before its process starts, so a daemon crashing in between
+	// does not run the job again, see models.JobModel.MarkStartingJobsRunning
+	record := models.NewJobAttemptModel(d.DB, p.ID, attempt)
+	if err := record.Save(); err != nil {
+		log.Printf("[WARNING] Failed when recording attempt: %+v", err)
+	}
+
+	log.Printf("Starting Job (attempt %d): %+v", attempt, p)
+	err = cmd.Start()
+	// The process has its own copy of the files
+	stdout.Close()
+	stderr.Close()
+	if err != nil {
+		joblog.RemoveSpool(logPath)
+		record.ExitCode = 127
+		if err := record.MarkAttemptFinished(); err != nil {
+			log.Printf("[WARNING] Failed when updating attempt: %+v", err)
+		}
+		return attemptResult{exitCode: 127, reason: payload.TERMINATION_START_FAILED}, err
+	}
+	rj.setProcess(cmd.Process.Pid)
+	defer rj.pid.Store(0)
+
+	spool, err := joblog.OpenSpool(logPath, logWriter)
+	if err != nil {
+		log.Printf("[WARNING] Failed to open the output of job %s, it is not logged: %v", p.ID, err)
+	}
+
+	record.PID = cmd.Process.Pid
+	if err := record.MarkAttemptStarted(); err != nil {
+		log.Printf("[WARNING] Failed when updating attempt: %+v", err)
+	}
+
+	job.Status = int(payload.JOB_RUNNING)
+	job.PID = cmd.Process.Pid
+	if job.PIDStartTime, err = processStartTime(job.PID); err != nil {
+		log.Printf("[WARNING] Failed to read start time of pid %d: %v", job.PID, err)
+	}
+	// A stopped job keeps its status, setProcess already terminated the process
+	if started, err := job.MarkJobStarted(); err != nil {
+		log.Printf("[WARNING] Failed when updating status: %+v", err)
+	} else if started {
+		d.publishJobEvent(job)
+	}
+
+	// Terminate the process group once the deadline passes
+	var timedOut atomic.Bool
+	waitDone := make(chan struct{})
+	if p.Timeout > 0 {
+		grace := p.KillGracePeriod
+		if grace == 0 {
+			grace = d.live().KillGracePeriod
+		}
+
+		timer := time.AfterFunc(p.Timeout, func() {
+			timedOut.Store(true)
+			log.Printf("Job %s exceeded its timeout of %v, terminating", p.ID, p.Timeout)
+			terminateProcessGroup(cmd.Process.Pid, grace, waitDone)
+		})
+		defer timer.Stop()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		if spool != nil {
+			drainSpool(p.ID, spool, waitDone)
+		}
+	}()
+
+	err = cmd.Wait()
+	close(waitDone)
+	<-drained
+
+	result := attemptResult{reason: payload.TERMINATION_EXITED}
+	if cmd.ProcessState == nil {
+		log.Printf("[WARNING] Failed when waiting job %s: %v", p.ID, err)
+		result.exitCode = 127
+	} else if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		result.signal = int(ws.Signal())
+		result.exitCode = 128 + result.signal
+		result.reason = payload.TERMINATION_SIGNALED
+	} else {
+		result.exitCode = cmd.ProcessState.ExitCode()
+	}
+	if timedOut.Load() {
+		result.reason = payload.TERMINATION_TIMEOUT
+	}
+
+	record.ExitCode = result.exitCode
+	if err := record.MarkAttemptFinished(); err != nil {
+		log.Printf("[WARNING] Failed when updating attempt: %+v", err)
+	}
+
+	return result, nil
+}
+
+// drainSpool frames the spooled output of a job into its logfile until done is closed, then
+// frames the rest and closes the spool.
+func drainSpool(jobID string, spool *joblog.Spool, done <-chan struct{}) {
+	ticker := time.NewTicker(spoolDrainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := spool.Drain(); err != nil {
+				log.Printf("[WARNING] Failed to log the output of job %s: %v", jobID, err)
+			}
+		case <-done:
+			if err := spool.Close(); err != nil {
+				log.Printf("[WARNING] Failed to log the output of job %s: %v", jobID, err)
+			}
+			return
+		}
+	}
+}
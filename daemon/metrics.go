@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"strings"
+
+	"github.com/mplus-oss/bobbit.go/internal/metrics"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+var (
+	jobsCreated = metrics.Default.NewCounterVec(
+		"bobbitd_jobs_created_total",
+		"Number of jobs submitted, by job name.",
+		"job_name",
+	)
+	jobsCompleted = metrics.Default.NewCounterVec(
+		"bobbitd_jobs_completed_total",
+		"Number of jobs reaching a final status, by job name and status (finished, failed, stopped, timed-out, skipped or lost).",
+		"job_name", "status",
+	)
+	jobDuration = metrics.Default.NewHistogramVec(
+		"bobbitd_job_duration_seconds",
+		"Time from the start of the first attempt to the end of the job, by job name.",
+		[]float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200, 21600},
+		"job_name",
+	)
+)
+
+// registerMetrics registers the metrics computed from the state of the daemon.
+func (d *DaemonStruct) registerMetrics() {
+	metrics.Default.NewGaugeFunc("bobbitd_jobs_running", "Number of jobs being executed.", func() float64 {
+		running, _ := d.countRunningJobs()
+		return float64(running)
+	})
+}
+
+// observeJobEvent counts the created and completed jobs from their lifecycle events.
+func observeJobEvent(event payload.JobEvent) {
+	switch event.Type {
+	case payload.EVENT_CREATED:
+		jobsCreated.Inc(event.JobName)
+	case payload.EVENT_FINISHED, payload.EVENT_STOPPED:
+		status := strings.ReplaceAll(strings.ToLower(payload.ParseJobStatus(event.Status)), " ", "-")
+		jobsCompleted.Inc(event.JobName, status)
+	}
+}
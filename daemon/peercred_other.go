@@ -0,0 +1,14 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// readPeerCredentials is only supported on Linux, see peercred_linux.go.
+func readPeerCredentials(conn net.Conn) (*PeerCredentials, error) {
+	return nil, fmt.Errorf("peer credentials are not supported on %s", runtime.GOOS)
+}
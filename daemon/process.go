@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// terminateProcessGroup sends SIGTERM to the process group of pid and escalates to
+// SIGKILL if the process has not exited after the grace period. The done channel must
+// be closed once the process has been reaped.
+func terminateProcessGroup(pid int, grace time.Duration, done <-chan struct{}) {
+	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
+		if !errors.Is(err, syscall.ESRCH) {
+			log.Printf("[WARNING] Failed to send SIGTERM to process group %d: %v", pid, err)
+		}
+		return
+	}
+
+	select {
+	case <-done:
+		return
+	case <-time.After(grace):
+	}
+
+	log.Printf("Process group %d is still alive after %v, sending SIGKILL", pid, grace)
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		log.Printf("[WARNING] Failed to send SIGKILL to process group %d: %v", pid, err)
+	}
+}
+
+// processStartTime returns the start time of the process in clock ticks since boot,
+// as reported by field 22 of /proc/<pid>/stat. Zombie processes are reported as not existing.
+func processStartTime(pid int) (uint64, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name (field 2) may contain spaces, so parse after its closing parenthesis
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("malformed stat of pid %d", pid)
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed stat of pid %d", pid)
+	}
+	if fields[0] == "Z" || fields[0] == "X" {
+		return 0, fmt.Errorf("pid %d is a zombie", pid)
+	}
+
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// isSameProcess reports whether pid is alive and is still the process that was started
+// at startTime. A reused PID has a different start time.
+func isSameProcess(pid int, startTime uint64) bool {
+	if pid <= 0 || startTime == 0 {
+		return false
+	}
+	current, err := processStartTime(pid)
+	return err == nil && current == startTime
+}
+
+// isJobProcess reports whether pid is alive and runs the job, for jobs whose process start time
+// was not recorded, e.g. started before it was. The process must lead its own process group,
+// as every job process does, and have the `JOB_ID` of the job in its environment.
+func isJobProcess(pid int, jobID string) bool {
+	if pid <= 0 {
+		return false
+	}
+	if pgid, err := syscall.Getpgid(pid); err != nil || pgid != pid {
+		return false
+	}
+
+	environ, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return false
+	}
+	for _, env := range bytes.Split(environ, []byte{0}) {
+		if string(env) == "JOB_ID="+jobID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/internal/joblog"
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// adoptedJobPollInterval is how often the daemon checks whether an adopted job process is still alive.
+// Adopted processes are not children of the daemon, so they can not be waited for.
+const adoptedJobPollInterval = time.Second
+
+// reconcileRunningJobs inspects the jobs marked as JOB_RUNNING by a previous daemon.
+// If the recorded process is still alive, the job is adopted and monitored until the process
+// exits. Otherwise, the job is marked as JOB_LOST. The results are kept for the doctor report.
+// Without recorded start time, the process is recognized by isJobProcess.
+func (d *DaemonStruct) reconcileRunningJobs() error {
+	jobModel, err := models.NewJobModel(d.DB, payload.JobResponse{})
+	if err != nil {
+		return err
+	}
+
+	// Claimed jobs whose attempt was recorded may have a process, see runAttempt
+	if starting, err := jobModel.MarkStartingJobsRunning(); err != nil {
+		return err
+	} else if starting > 0 {
+		log.Printf("Found %d job(s) started by the previous daemon without recorded process.", starting)
+	}
+
+	jobs, err := jobModel.GetRunningJobs()
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		result := payload.ReconciledJob{ID: job.ID, JobName: job.JobName, PID: job.PID}
+
+		adopt := isSameProcess(job.PID, job.PIDStartTime)
+		if !adopt && job.PIDStartTime == 0 && isJobProcess(job.PID, job.ID) {
+			// Record the start time, so the process is monitored like any other
+			if startTime, err := processStartTime(job.PID); err == nil {
+				job.PIDStartTime = startTime
+				if _, err := job.MarkJobStarted(); err != nil {
+					log.Printf("[WARNING] Failed when updating status: %+v", err)
+				}
+				adopt = true
+			}
+		}
+
+		if adopt {
+			result.Action = payload.RECONCILE_ADOPTED
+			result.Reason = "process is still alive"
+
+			rj := newRunningJob(job.Queue)
+			rj.pid.Store(int64(job.PID))
+			d.runningJobs.Store(job.ID, rj)
+			go d.monitorAdoptedJob(job, rj)
+		} else {
+			result.Action = payload.RECONCILE_LOST
+			startTime, err := processStartTime(job.PID)
+			switch {
+			case job.PID == 0:
+				result.Reason = "daemon stopped while the process was starting"
+			case job.PIDStartTime == 0:
+				result.Reason = "process is gone or does not run the job"
+			case err == nil && startTime != job.PIDStartTime:
+				result.Reason = "pid was reused by another process"
+			default:
+				result.Reason = "process is gone"
+			}
+
+			// The process may have written output since the previous daemon stopped
+			d.openAdoptedOutput(job).close()
+			d.markJobLost(job, result.Reason)
+		}
+
+		log.Printf("Reconciled job %s [pid %d]: %s (%s)", job.ID, job.PID, result.Action, result.Reason)
+		d.reconciled = append(d.reconciled, result)
+	}
+
+	return nil
+}
+
+// monitorAdoptedJob waits until the process of an adopted job exits. The job timeout is still
+// enforced from the start of its latest attempt. Since the exit code of an adopted process can not
+// be collected, the job is marked as JOB_LOST unless it was stopped or timed out.
+//
+// The output of the process is spooled to files, so it is framed into the logfile from where the
+// previous daemon stopped, see joblog.Spool.
+func (d *DaemonStruct) monitorAdoptedJob(job *models.JobModel, rj *runningJob) {
+	defer d.wakeDispatcher()
+	defer d.runningJobs.Delete(job.ID)
+
+	attempt := d.latestAttempt(job)
+	output := d.openAdoptedOutput(job)
+
+	var timedOut atomic.Bool
+	done := make(chan struct{})
+	if job.Timeout > 0 && attempt != nil {
+		grace := job.KillGracePeriod
+		if grace == 0 {
+			grace = d.live().KillGracePeriod
+		}
+
+		timer := time.AfterFunc(time.Until(attempt.StartedAt.Add(job.Timeout)), func() {
+			timedOut.Store(true)
+			log.Printf("Job %s exceeded its timeout of %v, terminating", job.ID, job.Timeout)
+			terminateProcessGroup(job.PID, grace, done)
+		})
+		defer timer.Stop()
+	}
+
+	ticker := time.NewTicker(adoptedJobPollInterval)
+	defer ticker.Stop()
+	for isSameProcess(job.PID, job.PIDStartTime) {
+		<-ticker.C
+		output.drain()
+	}
+	close(done)
+	output.close()
+
+	if attempt != nil {
+		if err := attempt.MarkAttemptFinished(); err != nil {
+			log.Printf("[WARNING] Failed when updating attempt: %+v", err)
+		}
+	}
+
+	// StopJob already updated the status of stopped jobs
+	if rj.stopped.Load() {
+		job.Status = int(payload.JOB_STOPPED)
+		job.TerminationReason = string(rj.stopReason)
+		d.publishJobEvent(job)
+		return
+	}
+	if timedOut.Load() {
+		job.TerminationReason = string(payload.TERMINATION_TIMEOUT)
+		if err := job.MarkJobFinished(); err != nil {
+			log.Printf("[WARNING] Failed when updating status: %+v", err)
+		} else {
+			d.publishJobEvent(job)
+		}
+		return
+	}
+
+	d.markJobLost(job, "adopted process exited, exit status is unknown")
+}
+
+// markJobLost marks a running job as JOB_LOST and explains the reason in its logfile.
+func (d *DaemonStruct) markJobLost(job *models.JobModel, reason string) {
+	changed, err := job.TransitionStatus(payload.JOB_RUNNING, payload.JOB_LOST)
+	if err != nil {
+		log.Printf("[WARNING] %v", err)
+		return
+	}
+	if changed {
+		d.appendJobLog(job, "===== [bobbit] Job lost: %s =====\n", reason)
+		d.publishJobEvent(job)
+	}
+}
+
+// adoptedOutput frames the spooled output of a job started by a previous daemon into its logfile.
+// A nil adoptedOutput does nothing, e.g. for jobs started before their output was spooled.
+type adoptedOutput struct {
+	jobID  string
+	writer *joblog.Writer
+	spool  *joblog.Spool
+}
+
+// openAdoptedOutput opens the spooled output of the job, or returns nil if it has none.
+func (d *DaemonStruct) openAdoptedOutput(job *models.JobModel) *adoptedOutput {
+	jobResp, err := job.ToPayload()
+	if err != nil {
+		log.Printf("[WARNING] Failed to open the output of job %s: %v", job.ID, err)
+		return nil
+	}
+	p := jobResp.JobDetailMetadata
+	logPath := config.GenerateJobLogPath(d.BobbitConfig, p)
+
+	writer, err := joblog.CreateWriter(logPath, d.logOptions(p))
+	if err != nil {
+		log.Printf("[WARNING] Failed to open logfile of job %s: %v", job.ID, err)
+		return nil
+	}
+	spool, err := joblog.OpenSpool(logPath, writer)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[WARNING] Failed to open the output of job %s: %v", job.ID, err)
+		}
+		writer.Close()
+		return nil
+	}
+	return &adoptedOutput{jobID: job.ID, writer: writer, spool: spool}
+}
+
+// drain frames the output spooled since the previous call.
+func (o *adoptedOutput) drain() {
+	if o == nil {
+		return
+	}
+	if err := o.spool.Drain(); err != nil {
+		log.Printf("[WARNING] Failed to log the output of job %s: %v", o.jobID, err)
+	}
+}
+
+// close frames the rest of the output, and removes the spool. The process must have exited.
+func (o *adoptedOutput) close() {
+	if o == nil {
+		return
+	}
+	if err := o.spool.Close(); err != nil {
+		log.Printf("[WARNING] Failed to log the output of job %s: %v", o.jobID, err)
+	}
+	if err := o.writer.Close(); err != nil {
+		log.Printf("[WARNING] Failed to close logfile of job %s: %v", o.jobID, err)
+	}
+}
+
+// latestAttempt returns the latest attempt of the job, or nil if it has none.
+func (d *DaemonStruct) latestAttempt(job *models.JobModel) *models.JobAttemptModel {
+	attempts, err := job.GetAttempts()
+	if err != nil {
+		log.Printf("[WARNING] Failed to fetch attempts of job %s: %v", job.ID, err)
+		return nil
+	}
+	if len(attempts) == 0 {
+		return nil
+	}
+	return attempts[len(attempts)-1]
+}
+
+// untrackedJobs returns the jobs marked as running in the database that are not
+// executed or monitored by the daemon.
+func (d *DaemonStruct) untrackedJobs(jobModel *models.JobModel) ([]payload.JobDependency, error) {
+	jobs, err := jobModel.GetRunningJobs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch running jobs: %w", err)
+	}
+
+	untracked := []payload.JobDependency{}
+	for _, job := range jobs {
+		if _, ok := d.runningJobs.Load(job.ID); !ok {
+			untracked = append(untracked, job.ToDependency())
+		}
+	}
+
+	return untracked, nil
+}
@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// liveConfig is the configuration in effect. Reload replaces it as a whole, so the readers
+// always see a consistent set of settings.
+type liveConfig struct {
+	config.BobbitDaemonConfig
+	// authPolicy controls what the clients may do. It is nil if every client may do anything.
+	authPolicy *config.AuthPolicy
+}
+
+// configChange is a setting that differs between two configurations. Without old value, new
+// describes the change.
+type configChange struct {
+	key      string
+	old, new any
+}
+
+func (c configChange) String() string {
+	if c.old == nil {
+		return fmt.Sprintf("%s: %v", c.key, c.new)
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.key, formatConfigValue(c.old), formatConfigValue(c.new))
+}
+
+// formatConfigValue quotes strings, so empty values are visible in the logs.
+func formatConfigValue(v any) string {
+	if reflect.ValueOf(v).Kind() == reflect.String {
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// live returns the configuration in effect.
+func (d *DaemonStruct) live() *liveConfig {
+	return d.current.Load()
+}
+
+// Config returns the configuration in effect, including the settings changed by Reload.
+// The embedded BobbitDaemonConfig of DaemonStruct is the configuration the daemon was started with.
+func (d *DaemonStruct) Config() config.BobbitDaemonConfig {
+	return d.live().BobbitDaemonConfig
+}
+
+// Reload applies the settings of the configuration that can change while the daemon runs:
+// debug mode, database pool, concurrency limits, job log defaults, kill grace period, retention
+// and the authorization policy, which is read again even if its path did not change.
+//
+// Running jobs keep the settings they were started with, and the listeners are not touched.
+// Other changed settings are logged as requiring a restart. If the authorization policy cannot
+// be loaded, nothing is applied.
+func (d *DaemonStruct) Reload(c config.BobbitDaemonConfig) error {
+	current := d.live()
+
+	var authPolicy *config.AuthPolicy
+	if c.AuthPolicyPath != "" {
+		var err error
+		if authPolicy, err = config.LoadAuthPolicy(c.AuthPolicyPath); err != nil {
+			return &DaemonError{"Failed to load auth policy", err, payload.ERROR_INTERNAL}
+		}
+	}
+
+	next := &liveConfig{BobbitDaemonConfig: current.BobbitDaemonConfig, authPolicy: authPolicy}
+	next.DebugMode = c.DebugMode
+	next.DBMaxOpenConn = c.DBMaxOpenConn
+	next.DBMaxIdleConn = c.DBMaxIdleConn
+	next.MaxConcurrentJobs = c.MaxConcurrentJobs
+	next.QueueLimits = c.QueueLimits
+	next.KillGracePeriod = c.KillGracePeriod
+	next.LogMaxSize = c.LogMaxSize
+	next.LogPolicy = c.LogPolicy
+	next.LogMaxSegments = c.LogMaxSegments
+	next.LogCompression = c.LogCompression
+	next.RetentionMaxAge = c.RetentionMaxAge
+	next.RetentionFailedMaxAge = c.RetentionFailedMaxAge
+	next.RetentionMaxPerName = c.RetentionMaxPerName
+	next.RetentionInterval = c.RetentionInterval
+	next.AuthPolicyPath = c.AuthPolicyPath
+
+	applied := diffConfig(current, next)
+	restart := diffConfig(&liveConfig{BobbitDaemonConfig: next.BobbitDaemonConfig}, &liveConfig{BobbitDaemonConfig: c})
+	d.current.Store(next)
+
+	d.DB.SetMaxOpenConns(next.DBMaxOpenConn)
+	d.DB.SetMaxIdleConns(next.DBMaxIdleConn)
+	// Raised limits may allow queued jobs to start, and the janitor must pick up its new interval
+	d.wakeDispatcher()
+	d.wakeJanitor()
+
+	if len(applied) == 0 && len(restart) == 0 {
+		log.Println("Configuration reloaded, nothing changed.")
+	}
+	for _, change := range applied {
+		log.Printf("Configuration reloaded: %v", change)
+	}
+	for _, change := range restart {
+		log.Printf("[WARNING] Configuration changed but requires a restart: %v", change)
+	}
+	return nil
+}
+
+// diffConfig lists the settings that differ between both configurations, by their key in
+// the configuration file.
+func diffConfig(a *liveConfig, b *liveConfig) []configChange {
+	var changes []configChange
+	check := func(key string, old any, new any) {
+		if !reflect.DeepEqual(old, new) {
+			changes = append(changes, configChange{key, old, new})
+		}
+	}
+
+	check("debug", a.DebugMode, b.DebugMode)
+	check("socket_path", a.SocketPath, b.SocketPath)
+	check("data_dir", a.DataPath, b.DataPath)
+	check("db_max_open_conn", a.DBMaxOpenConn, b.DBMaxOpenConn)
+	check("db_max_idle_conn", a.DBMaxIdleConn, b.DBMaxIdleConn)
+	check("kill_grace_period", a.KillGracePeriod, b.KillGracePeriod)
+	check("max_concurrent_jobs", a.MaxConcurrentJobs, b.MaxConcurrentJobs)
+	check("queue_limits", a.QueueLimits, b.QueueLimits)
+	check("log_max_size", a.LogMaxSize, b.LogMaxSize)
+	check("log_policy", a.LogPolicy, b.LogPolicy)
+	check("log_max_segments", a.LogMaxSegments, b.LogMaxSegments)
+	check("log_compression", a.LogCompression, b.LogCompression)
+	check("retention_max_age", a.RetentionMaxAge, b.RetentionMaxAge)
+	check("retention_failed_max_age", a.RetentionFailedMaxAge, b.RetentionFailedMaxAge)
+	check("retention_max_per_name", a.RetentionMaxPerName, b.RetentionMaxPerName)
+	check("retention_interval", a.RetentionInterval, b.RetentionInterval)
+	check("auth_policy", a.AuthPolicyPath, b.AuthPolicyPath)
+	if a.AuthPolicyPath == b.AuthPolicyPath && !reflect.DeepEqual(a.authPolicy, b.authPolicy) {
+		changes = append(changes, configChange{"auth_policy", nil, "content of " + b.AuthPolicyPath + " changed"})
+	}
+	check("socket_mode", a.SocketMode, b.SocketMode)
+	check("socket_owner", a.SocketOwner, b.SocketOwner)
+	check("listen_tcp", a.ListenTCP, b.ListenTCP)
+	check("tls_cert", a.TLSCertPath, b.TLSCertPath)
+	check("tls_key", a.TLSKeyPath, b.TLSKeyPath)
+	check("tls_client_ca", a.TLSClientCAPath, b.TLSClientCAPath)
+	check("http_listen", a.HTTPListen, b.HTTPListen)
+	check("metrics_listen", a.MetricsListen, b.MetricsListen)
+	return changes
+}
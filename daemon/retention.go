@@ -0,0 +1,174 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/config"
+	"github.com/mplus-oss/bobbit.go/internal/joblog"
+	"github.com/mplus-oss/bobbit.go/internal/lib"
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// failedJobStatuses are the statuses kept for RetentionFailedMaxAge instead of RetentionMaxAge.
+var failedJobStatuses = []payload.JobStatusEnum{payload.JOB_FAILED, payload.JOB_TIMED_OUT, payload.JOB_LOST}
+
+// RunJanitor prunes finished jobs according to the retention config every RetentionInterval.
+// The retention config can be reloaded, so it keeps running even if no retention is configured.
+// This function blocks forever.
+func (d *DaemonStruct) RunJanitor() {
+	for {
+		c := d.live()
+		if c.RetentionMaxAge > 0 || c.RetentionFailedMaxAge > 0 || c.RetentionMaxPerName > 0 {
+			d.enforceRetention(c)
+		}
+
+		timer := time.NewTimer(c.RetentionInterval)
+		select {
+		case <-timer.C:
+		case <-d.janitorWake:
+		}
+		timer.Stop()
+	}
+}
+
+// wakeJanitor asks the janitor to enforce the retention config now, e.g. after a reload.
+// It never blocks; multiple wake-ups before the janitor runs are coalesced.
+func (d *DaemonStruct) wakeJanitor() {
+	select {
+	case d.janitorWake <- struct{}{}:
+	default:
+	}
+}
+
+// enforceRetention prunes the jobs that exceed the retention config.
+// Failed jobs are pruned separately as they may be kept longer.
+func (d *DaemonStruct) enforceRetention(c *liveConfig) {
+	requests := []payload.PruneRequestMetadata{
+		{
+			OlderThan:  c.RetentionMaxAge,
+			Statuses:   []payload.JobStatusEnum{payload.JOB_FINISH, payload.JOB_STOPPED, payload.JOB_SKIPPED},
+			MaxPerName: c.RetentionMaxPerName,
+		},
+		{
+			OlderThan:  c.RetentionFailedMaxAge,
+			Statuses:   failedJobStatuses,
+			MaxPerName: c.RetentionMaxPerName,
+		},
+	}
+
+	for _, req := range requests {
+		if req.OlderThan <= 0 && req.MaxPerName <= 0 {
+			continue
+		}
+
+		resp, err := d.pruneJobs(req)
+		if err != nil {
+			log.Printf("[WARNING] Retention: %v", err)
+			continue
+		}
+		if len(resp.Jobs) > 0 {
+			log.Printf("Retention: pruned %d jobs, reclaimed %s", len(resp.Jobs), lib.HumanizeBytes(resp.ReclaimedBytes))
+		}
+	}
+}
+
+// pruneJobs deletes the finished jobs matching the request together with their logfiles.
+// A job matches if it was finished longer than OlderThan ago, or if it is older than the
+// latest MaxPerName jobs of the same name. Jobs that an unfinished job depends on are kept.
+func (d *DaemonStruct) pruneJobs(req payload.PruneRequestMetadata) (*payload.PruneResponse, error) {
+	if req.OlderThan < 0 || req.MaxPerName < 0 {
+		return nil, &DaemonError{"Invalid prune request", fmt.Errorf("older than and max per name cannot be negative"), payload.ERROR_INVALID_REQUEST}
+	}
+	if req.OlderThan == 0 && req.MaxPerName == 0 {
+		return nil, &DaemonError{"Invalid prune request", fmt.Errorf("older than or max per name is required"), payload.ERROR_INVALID_REQUEST}
+	}
+
+	statuses := payload.JobStatusEnum(0)
+	for _, status := range req.Statuses {
+		if !status.IsFinished() {
+			return nil, &DaemonError{"Invalid prune request", fmt.Errorf("status %s is not a final status", payload.ParseJobStatus(status)), payload.ERROR_INVALID_REQUEST}
+		}
+		statuses |= status
+	}
+	if statuses == 0 {
+		statuses = payload.FinalJobStatuses
+	}
+
+	jobModel, err := models.NewJobModel(d.DB, payload.JobResponse{})
+	if err != nil {
+		return nil, &DaemonError{"Failed when initialize db model", err, payload.ERROR_INTERNAL}
+	}
+	candidates, err := jobModel.GetPruneCandidates()
+	if err != nil {
+		return nil, &DaemonError{"Failed when fetching finished jobs", err, payload.ERROR_INTERNAL}
+	}
+
+	cutoff := time.Now().Add(-req.OlderThan)
+	perName := map[string]int{}
+	var pruned []*models.JobModel
+	for _, job := range candidates {
+		// Candidates are sorted from the newest job of every name
+		perName[job.JobName]++
+
+		if payload.JobStatusEnum(job.Status)&statuses == 0 {
+			continue
+		}
+		expired := req.OlderThan > 0 && job.UpdatedAt.Before(cutoff)
+		excess := req.MaxPerName > 0 && perName[job.JobName] > req.MaxPerName
+		if expired || excess {
+			pruned = append(pruned, job)
+		}
+	}
+
+	resp := &payload.PruneResponse{Jobs: []payload.JobDependency{}, DryRun: req.DryRun}
+	if len(pruned) == 0 {
+		return resp, nil
+	}
+
+	if !req.DryRun {
+		ids := make([]string, len(pruned))
+		for i, job := range pruned {
+			ids[i] = job.ID
+		}
+		if _, err := jobModel.DeleteJobs(ids); err != nil {
+			return nil, &DaemonError{"Failed when deleting jobs", err, payload.ERROR_INTERNAL}
+		}
+	}
+
+	for _, job := range pruned {
+		resp.Jobs = append(resp.Jobs, job.ToDependency())
+
+		logPath := config.GenerateJobLogPath(d.BobbitConfig, payload.JobDetailMetadata{
+			ID:        job.ID,
+			CreatedAt: job.CreatedAt,
+		})
+		if logPath == "" {
+			continue
+		}
+
+		if req.DryRun {
+			size, _ := joblog.Size(logPath)
+			resp.ReclaimedBytes += size
+			continue
+		}
+
+		freed, err := joblog.Remove(logPath)
+		resp.ReclaimedBytes += freed
+		if err != nil {
+			log.Printf("[WARNING] Failed to remove logfile of job %s: %v", job.ID, err)
+		}
+
+		// Remove the month and year directories once they are empty
+		monthDir := filepath.Dir(logPath)
+		if os.Remove(monthDir) == nil {
+			os.Remove(filepath.Dir(monthDir))
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,189 @@
+package daemon
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+	"github.com/robfig/cron/v3"
+)
+
+// ParseScheduleExpression parses a standard 5-field cron expression, a descriptor
+// such as `@daily`, or an interval such as `@every 5m`.
+func ParseScheduleExpression(expression string) (cron.Schedule, error) {
+	return cron.ParseStandard(expression)
+}
+
+// RunScheduler spawns jobs from the active schedules when they are due.
+// Schedules that were due while the daemon was down fire once on startup.
+// This function blocks forever.
+func (d *DaemonStruct) RunScheduler() {
+	scheduleModel := &models.ScheduleModel{BaseModel: models.BaseModel{DB: d.DB}}
+
+	for {
+		next := d.runDueSchedules(scheduleModel)
+
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timerC = timer.C
+		}
+
+		select {
+		case <-timerC:
+		case <-d.scheduleWake:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// wakeScheduler asks the scheduler to reload the schedules.
+// It never blocks; multiple wake-ups before the scheduler runs are coalesced.
+func (d *DaemonStruct) wakeScheduler() {
+	select {
+	case d.scheduleWake <- struct{}{}:
+	default:
+	}
+}
+
+// runDueSchedules fires every due schedule and returns the earliest next run time.
+// It returns zero time if there is no active schedule.
+func (d *DaemonStruct) runDueSchedules(scheduleModel *models.ScheduleModel) time.Time {
+	schedules, err := scheduleModel.Get(true)
+	if err != nil {
+		log.Printf("[WARNING] Failed to fetch schedules: %v", err)
+		return time.Now().Add(time.Minute)
+	}
+
+	var earliest time.Time
+	for _, s := range schedules {
+		expression, err := ParseScheduleExpression(s.Expression)
+		if err != nil {
+			log.Printf("[WARNING] Invalid expression of schedule %s: %v", s.Name, err)
+			continue
+		}
+
+		now := time.Now()
+		if s.NextRunAt.Valid && !s.NextRunAt.Time.After(now) {
+			if _, err := d.fireSchedule(s.ID, false); err != nil {
+				log.Printf("Schedule %s: %v", s.Name, err)
+			}
+		}
+
+		// Schedules paused in the meantime keep their empty next run
+		if !s.NextRunAt.Valid || !s.NextRunAt.Time.After(now) {
+			s.NextRunAt = sql.NullTime{Time: expression.Next(now).UTC(), Valid: true}
+			if err := s.SetNextRun(); err != nil {
+				log.Printf("[WARNING] %v", err)
+			}
+		}
+
+		if earliest.IsZero() || s.NextRunAt.Time.Before(earliest) {
+			earliest = s.NextRunAt.Time
+		}
+	}
+
+	return earliest
+}
+
+// fireSchedule spawns a job from the template of the schedule with the ID through SubmitJob,
+// honouring the overlap policy of the schedule. Paused schedules are only fired if manual is true.
+//
+// Runs are serialized and the schedule is read again, so a manual trigger and the scheduler
+// always see the job spawned by each other.
+func (d *DaemonStruct) fireSchedule(id string, manual bool) (*payload.JobResponse, error) {
+	d.scheduleMu.Lock()
+	defer d.scheduleMu.Unlock()
+
+	scheduleModel := &models.ScheduleModel{BaseModel: models.BaseModel{DB: d.DB}}
+	s, err := scheduleModel.Find(id)
+	if err != nil {
+		return nil, &DaemonError{"Failed when finding schedule", err, payload.ERROR_INTERNAL}
+	}
+	if s == nil || s.ID != id {
+		return nil, &DaemonError{"Schedule not found", fmt.Errorf("id: %s", id), payload.ERROR_SCHEDULE_NOT_FOUND}
+	}
+	if s.Paused && !manual {
+		return nil, &DaemonError{"Skipped run", fmt.Errorf("schedule is paused"), payload.ERROR_CONFLICT}
+	}
+
+	scheduleResp, err := s.ToPayload()
+	if err != nil {
+		return nil, &DaemonError{"Failed when parsing the schedule", err, payload.ERROR_INTERNAL}
+	}
+
+	p := scheduleResp.Job
+	p.ID = ""
+	p.CreatedAt = time.Time{}
+	p.UpdatedAt = time.Time{}
+	p.DependsOn = nil
+	p.NotBefore = nil
+	if p.JobName == "" {
+		p.JobName = s.Name
+	}
+
+	// Check whether the previous job is still active
+	if lastJob := d.findJobByID(s.LastJobID); lastJob != nil && !payload.JobStatusEnum(lastJob.Status).IsFinished() {
+		switch payload.OverlapPolicyEnum(s.OverlapPolicy) {
+		case payload.OVERLAP_ALLOW:
+		case payload.OVERLAP_QUEUE:
+			p.DependsOn = []string{lastJob.ID}
+			p.DependencyCondition = payload.DEPENDENCY_ON_COMPLETION
+		default:
+			s.LastRunAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+			if err := s.MarkRun(); err != nil {
+				log.Printf("[WARNING] %v", err)
+			}
+			return nil, &DaemonError{"Skipped run", fmt.Errorf("previous job %s is still active", lastJob.ID), payload.ERROR_CONFLICT}
+		}
+	}
+
+	// Spawned jobs belong to the owner of the schedule
+	job, err := d.SubmitJob(p, s.Owner())
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Schedule %s spawned job %s", s.Name, job.ID)
+
+	s.LastJobID = job.ID
+	s.LastRunAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	if err := s.MarkRun(); err != nil {
+		log.Printf("[WARNING] %v", err)
+	}
+
+	return job, nil
+}
+
+// findJobByID fetch a job by its full ID. It returns nil if the job does not exist.
+func (d *DaemonStruct) findJobByID(id string) *models.JobModel {
+	if id == "" {
+		return nil
+	}
+
+	jobModel, err := models.NewJobModel(d.DB, payload.JobResponse{})
+	if err != nil {
+		log.Printf("[WARNING] Failed when initialize db model: %v", err)
+		return nil
+	}
+
+	jobs, err := jobModel.Get(&models.JobFilter{
+		HideCommand: true,
+		DBGetFilter: models.DBGetFilter{ID: id, Limit: 1},
+	})
+	if err != nil {
+		log.Printf("[WARNING] Failed to fetch job %s: %v", id, err)
+		return nil
+	}
+	if len(jobs) < 1 || jobs[0].ID != id {
+		return nil
+	}
+
+	return jobs[0]
+}
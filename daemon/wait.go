@@ -0,0 +1,154 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mplus-oss/bobbit.go/internal/lib"
+	"github.com/mplus-oss/bobbit.go/metadata/models"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// waitJobs blocks until the jobs of the request are finished according to its mode, and returns
+// the waited jobs. If the context is done first, the waited jobs are returned with its error.
+//
+// The database is only queried for the initial state and when an event may change the
+// result, e.g. when a waited job is finished or a job matching a target is created.
+// Only the jobs of ownerUID are waited for, unless it is nil.
+func (d *DaemonStruct) waitJobs(ctx context.Context, jobModel *models.JobModel, req payload.WaitRequestMetadata, ownerUID *int) ([]*models.JobModel, error) {
+	targets := req.Targets
+	if req.Search != "" {
+		targets = append([]string{req.Search}, targets...)
+	}
+
+	// found marks the targets that matched a job at least once
+	found := make([]bool, len(targets))
+	for {
+		// Subscribe before querying, so no transition is missed in between
+		events, unsubscribe := d.events.subscribe()
+
+		jobs, missing, err := d.findWaitedJobs(jobModel, targets, req.MetadataFilter, ownerUID, found)
+		if err != nil {
+			unsubscribe()
+			return nil, err
+		}
+
+		waited := map[string]bool{}
+		finished := 0
+		for _, job := range jobs {
+			if payload.JobStatusEnum(job.Status).IsFinished() {
+				finished++
+			} else {
+				waited[job.ID] = true
+			}
+		}
+		if req.Mode == payload.WAIT_ANY && finished > 0 {
+			unsubscribe()
+			return jobs, nil
+		}
+		if req.Mode != payload.WAIT_ANY && missing == 0 && len(jobs) > 0 && finished == len(jobs) {
+			unsubscribe()
+			return jobs, nil
+		}
+
+		metadataFilter := payload.WatchRequestMetadata{MetadataFilter: req.MetadataFilter}
+		err = waitForEvent(ctx, events, func(event payload.JobEvent) bool {
+			switch {
+			case waited[event.ID]:
+				return event.Status.IsFinished()
+			case event.Type == payload.EVENT_CREATED:
+				for _, target := range targets {
+					if matchesSearch(target, event) {
+						return true
+					}
+				}
+				return len(req.MetadataFilter) > 0 && matchesWatch(metadataFilter, event)
+			case event.Type == payload.EVENT_METADATA_UPDATED:
+				return len(req.MetadataFilter) > 0 && matchesWatch(metadataFilter, event)
+			}
+			return false
+		})
+		unsubscribe()
+		if err != nil {
+			return jobs, err
+		}
+	}
+}
+
+// findWaitedJobs fetch the latest job of every target and every job matching the metadata filter,
+// without duplicates. It returns the number of targets without any matching job.
+// A target that matched a job before must still match one, see found.
+func (d *DaemonStruct) findWaitedJobs(jobModel *models.JobModel, targets []string, metadataFilter map[string]string, ownerUID *int, found []bool) ([]*models.JobModel, int, error) {
+	var jobs []*models.JobModel
+	seen := map[string]bool{}
+	missing := 0
+
+	for i, target := range targets {
+		matches, err := jobModel.Get(&models.JobFilter{
+			OwnerUID:             ownerUID,
+			GeneralKeywordSearch: target,
+			HideCommand:          true,
+			DBGetFilter: models.DBGetFilter{
+				Limit:    1,
+				SortDesc: true,
+			},
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(matches) == 0 {
+			if found[i] {
+				return nil, 0, fmt.Errorf("Job %s was found but is no longer available", target)
+			}
+			missing++
+			continue
+		}
+
+		found[i] = true
+		if !seen[matches[0].ID] {
+			seen[matches[0].ID] = true
+			jobs = append(jobs, matches[0])
+		}
+	}
+
+	if len(metadataFilter) > 0 {
+		matches, err := jobModel.Get(&models.JobFilter{
+			OwnerUID:       ownerUID,
+			MetadataFilter: metadataFilter,
+			HideCommand:    true,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, job := range matches {
+			if !seen[job.ID] {
+				seen[job.ID] = true
+				jobs = append(jobs, job)
+			}
+		}
+	}
+
+	return jobs, missing, nil
+}
+
+// waitForEvent blocks until an event satisfies match, the subscription is dropped
+// by the event bus, or the context is done.
+func waitForEvent(ctx context.Context, events <-chan payload.JobEvent, match func(payload.JobEvent) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok || match(event) {
+				return nil
+			}
+		}
+	}
+}
+
+// matchesSearch reports whether the job of the event matches a search by ID prefix or
+// job name, see JobFilter.GeneralKeywordSearch.
+func matchesSearch(search string, event payload.JobEvent) bool {
+	return strings.HasPrefix(event.ID, search) || lib.MatchLike(search, event.JobName)
+}
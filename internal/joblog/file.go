@@ -0,0 +1,182 @@
+package joblog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// Options defines the size limit of a logfile.
+type Options struct {
+	// MaxSize is the maximum size of the logfile in bytes. Zero means unlimited.
+	MaxSize int64
+	// Policy defines what happens when the logfile reaches MaxSize. Defaults to LOG_POLICY_ROTATE.
+	Policy payload.LogPolicyEnum
+	// MaxSegments is the number of rotated segments kept by LOG_POLICY_ROTATE. Zero means unlimited.
+	MaxSegments int
+}
+
+// logFile is an append-only logfile that enforces the size limit of its options.
+type logFile struct {
+	path       string
+	opts       Options
+	f          *os.File
+	size       int64
+	discarding bool
+}
+
+func openLogFile(path string, opts Options) (*logFile, error) {
+	if opts.Policy == "" {
+		opts.Policy = payload.LOG_POLICY_ROTATE
+	}
+
+	l := &logFile{path: path, opts: opts}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *logFile) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.f = f
+	l.size = info.Size()
+	return nil
+}
+
+func (l *logFile) Write(p []byte) (int, error) {
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+func (l *logFile) Close() error {
+	return l.f.Close()
+}
+
+// reserve makes room for a frame of n bytes according to the policy.
+// It returns true if the frame must be discarded.
+func (l *logFile) reserve(n int64) (bool, error) {
+	if l.discarding {
+		return true, nil
+	}
+	if l.opts.MaxSize <= 0 || l.size+n <= l.opts.MaxSize {
+		return false, nil
+	}
+
+	switch l.opts.Policy {
+	case payload.LOG_POLICY_STOP:
+		l.discarding = true
+		notice := fmt.Sprintf("%s\t%s\t===== [bobbit] Log reached its maximum size of %d bytes, output is discarded =====\n",
+			payload.LOG_STREAM_DAEMON, time.Now().UTC().Format(time.RFC3339Nano), l.opts.MaxSize)
+		_, err := io.WriteString(l, notice)
+		return true, err
+	case payload.LOG_POLICY_TRUNCATE:
+		return false, l.truncateHead()
+	default:
+		return false, l.rotate()
+	}
+}
+
+// rotate moves the logfile into the next numbered segment and opens a new logfile.
+func (l *logFile) rotate() error {
+	segments, err := Segments(l.path)
+	if err != nil {
+		return err
+	}
+	next := 1
+	for _, segment := range segments {
+		if segment.Number >= next {
+			next = segment.Number + 1
+		}
+	}
+
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path, fmt.Sprintf("%s.%d", l.path, next)); err != nil {
+		return err
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	// Only rotated segments count towards MaxSegments
+	if l.opts.MaxSegments > 0 {
+		segments, err := Segments(l.path)
+		if err != nil {
+			return err
+		}
+		rotated := segments[:len(segments)-1]
+		for len(rotated) > l.opts.MaxSegments {
+			if err := os.Remove(rotated[0].Path); err != nil {
+				log.Printf("[WARNING] Failed to remove log segment %s: %v", rotated[0].Path, err)
+			}
+			rotated = rotated[1:]
+		}
+	}
+
+	return nil
+}
+
+// truncateHead drops the oldest half of the logfile. The logfile is replaced atomically
+// and only whole lines are kept.
+func (l *logFile) truncateHead() error {
+	keep := l.opts.MaxSize / 2
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+
+	src, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if offset := l.size - keep; offset > 0 {
+		if _, err := src.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	reader := bufio.NewReader(src)
+	if l.size > keep {
+		// Skip the partial line at the cut
+		if _, err := reader.ReadString('\n'); err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	tmpPath := l.path + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, reader); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return err
+	}
+
+	return l.open()
+}
@@ -0,0 +1,209 @@
+package joblog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// Follower reads the lines appended to the active segment of a log while it is written.
+//
+// A rotation replaces the active segment: the rest of the previous one is read through the
+// open file, then the segments rotated after it, so no line is lost unless the segments were
+// already deleted, see Options.MaxSegments. The new active segment is read from its start.
+// A truncation, see payload.LOG_POLICY_TRUNCATE, replaces it with its latest lines, which are
+// skipped as they were already read. Lines cut by several truncations between two polls are lost.
+type Follower struct {
+	path string
+	file *os.File
+	// offset is the position in file after the last complete line
+	offset  int64
+	partial []byte
+}
+
+// Follow opens the active segment of the log at path to follow it from its start.
+func Follow(path string) (*Follower, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Follower{path: path, file: f}, nil
+}
+
+// Poll returns the complete lines appended since the previous call, without their newline.
+func (f *Follower) Poll() ([]string, error) {
+	current, err := os.Stat(f.path)
+	if err != nil {
+		// The active segment is gone, e.g. compressed once the job finished, but the open
+		// file still has its content
+		return f.readLines()
+	}
+	if info, err := f.file.Stat(); err != nil || os.SameFile(info, current) {
+		return f.readLines()
+	}
+
+	lines, err := f.readLines()
+	if err != nil {
+		return lines, err
+	}
+	// The previous segment is complete
+	if len(f.partial) > 0 {
+		lines = append(lines, string(f.partial))
+		f.partial = nil
+	}
+
+	// The new active segment is opened first, so the segments rotated in the meantime are listed
+	next, err := os.Open(f.path)
+	if err != nil {
+		return lines, err
+	}
+	rotated, err := f.rotatedSince(next)
+	if err != nil {
+		next.Close()
+		return lines, err
+	}
+	for _, segment := range rotated {
+		if lines, err = readSegmentLines(segment, lines); err != nil {
+			next.Close()
+			return lines, err
+		}
+	}
+
+	skip := f.copiedLength(next)
+	f.file.Close()
+	f.file = next
+	f.offset = skip
+	f.partial = nil
+
+	more, err := f.readLines()
+	return append(lines, more...), err
+}
+
+// rotatedSince returns the segments rotated after the current file, except next. If the
+// current file is not a segment anymore, every remaining one is newer.
+func (f *Follower) rotatedSince(next *os.File) ([]Segment, error) {
+	segments, err := Segments(f.path)
+	if err != nil {
+		return nil, err
+	}
+	current, err := f.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	nextInfo, err := next.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var rotated []Segment
+	for _, segment := range segments {
+		info, err := os.Stat(segment.Path)
+		if segment.Number == 0 || err != nil || os.SameFile(info, nextInfo) {
+			continue
+		}
+		if os.SameFile(info, current) {
+			rotated = nil
+			continue
+		}
+		rotated = append(rotated, segment)
+	}
+	return rotated, nil
+}
+
+// readSegmentLines appends the lines of the complete segment to lines.
+func readSegmentLines(segment Segment, lines []string) ([]string, error) {
+	reader, err := openSegment(segment)
+	if errors.Is(err, os.ErrNotExist) {
+		// Deleted in the meantime
+		return lines, nil
+	}
+	if err != nil {
+		return lines, err
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// Rest returns the remaining lines, including the last one if it has no newline. It must be
+// called once the log is complete.
+func (f *Follower) Rest() ([]string, error) {
+	lines, err := f.Poll()
+	if len(f.partial) > 0 {
+		lines = append(lines, string(f.partial))
+		f.offset += int64(len(f.partial))
+		f.partial = nil
+	}
+	return lines, err
+}
+
+// Close closes the active segment.
+func (f *Follower) Close() error {
+	return f.file.Close()
+}
+
+// readLines reads the complete lines appended to the file since the previous call.
+func (f *Follower) readLines() ([]string, error) {
+	var lines []string
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.file.ReadAt(buf, f.offset+int64(len(f.partial)))
+		f.partial = append(f.partial, buf[:n]...)
+		for {
+			i := bytes.IndexByte(f.partial, '\n')
+			if i < 0 {
+				break
+			}
+			lines = append(lines, string(f.partial[:i]))
+			f.partial = f.partial[i+1:]
+			f.offset += int64(i + 1)
+		}
+
+		if err == io.EOF || n == 0 {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
+
+// copiedLength returns the length of the head of next that a truncation copied from the
+// current file, which was read entirely. A rotated file is still linked as a segment, so
+// nothing was copied from it.
+func (f *Follower) copiedLength(next *os.File) int64 {
+	info, err := f.file.Stat()
+	if err != nil {
+		return 0
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || stat.Nlink > 0 {
+		return 0
+	}
+
+	first, err := bufio.NewReader(io.NewSectionReader(next, 0, 1<<62)).ReadString('\n')
+	if err != nil {
+		return 0
+	}
+
+	// The copy starts at a line of the current file
+	reader := bufio.NewReader(io.NewSectionReader(f.file, 0, info.Size()))
+	var pos int64
+	for {
+		line, err := reader.ReadString('\n')
+		if line == first {
+			return info.Size() - pos
+		}
+		pos += int64(len(line))
+		if err != nil {
+			return 0
+		}
+	}
+}
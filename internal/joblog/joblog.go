@@ -0,0 +1,164 @@
+// Package joblog reads and writes job logfiles.
+//
+// Jobs store stdout, stderr and daemon messages in a single framed file. Every line is
+// written as `<stream>\t<RFC3339Nano timestamp>\t<text>`, so the order of the streams is kept.
+// Logfiles of older jobs contain the raw output without any framing (FORMAT_RAW).
+package joblog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+const (
+	// FORMAT_RAW is the format of logfiles written before the streams were captured separately.
+	FORMAT_RAW = ""
+	// FORMAT_FRAMED is the format where every line is tagged with its stream and timestamp.
+	FORMAT_FRAMED = "framed"
+)
+
+// maxLineSize is the maximum length of a single frame. Longer lines are split into multiple frames.
+const maxLineSize = 64 * 1024
+
+// Writer writes framed lines into a logfile. It is safe for concurrent use.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	// file is set if the Writer owns the logfile, see CreateWriter.
+	file *logFile
+}
+
+// NewWriter creates a Writer that writes framed lines into w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// CreateWriter opens the logfile at path for appending and creates a Writer that enforces
+// the size limit of the options.
+func CreateWriter(path string, opts Options) (*Writer, error) {
+	f, err := openLogFile(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: f, file: f}, nil
+}
+
+// Close closes the logfile if the Writer owns it.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// WriteLine writes a single line of the stream. Newlines inside the text are written as separate lines.
+func (w *Writer) WriteLine(stream payload.LogStreamEnum, text string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		frame := fmt.Sprintf("%s\t%s\t%s\n", stream, ts, line)
+		if w.file != nil {
+			discard, err := w.file.reserve(int64(len(frame)))
+			if err != nil {
+				return err
+			}
+			if discard {
+				continue
+			}
+		}
+		if _, err := io.WriteString(w.w, frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Printf formats a daemon message and writes it as LOG_STREAM_DAEMON.
+func (w *Writer) Printf(format string, v ...any) error {
+	return w.WriteLine(payload.LOG_STREAM_DAEMON, fmt.Sprintf(format, v...))
+}
+
+// streamWriter splits the output of a job process into lines. Partial lines are buffered until
+// a newline is written or the stream is closed.
+type streamWriter struct {
+	w      *Writer
+	stream payload.LogStreamEnum
+	buf    []byte
+	// failing is set while lines fail to be written, so the error is only logged once
+	failing bool
+}
+
+// Write never fails: lines that cannot be written, e.g. on a full disk, are logged and dropped,
+// so the output keeps being consumed.
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			break
+		}
+		s.writeLine(string(s.buf[:i]))
+		s.buf = s.buf[i+1:]
+	}
+
+	for len(s.buf) >= maxLineSize {
+		s.writeLine(string(s.buf[:maxLineSize]))
+		s.buf = s.buf[maxLineSize:]
+	}
+
+	return len(p), nil
+}
+
+func (s *streamWriter) writeLine(line string) {
+	err := s.w.WriteLine(s.stream, line)
+	if err != nil && !s.failing {
+		log.Printf("[WARNING] Failed to write %s to logfile, dropping output: %v", s.stream, err)
+	}
+	s.failing = err != nil
+}
+
+// Close flushes the last partial line.
+func (s *streamWriter) Close() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	err := s.w.WriteLine(s.stream, string(s.buf))
+	s.buf = nil
+	return err
+}
+
+// ParseLine parses a line of a logfile written in the format. Lines of FORMAT_RAW logfiles,
+// and lines that are not valid frames, are returned as is without stream and timestamp.
+func ParseLine(format, line string) payload.LogLine {
+	if format != FORMAT_FRAMED {
+		return payload.LogLine{Line: line}
+	}
+
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return payload.LogLine{Line: line}
+	}
+	ts, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return payload.LogLine{Line: line}
+	}
+
+	return payload.LogLine{
+		Line:      parts[2],
+		Stream:    payload.LogStreamEnum(parts[0]),
+		Timestamp: &ts,
+	}
+}
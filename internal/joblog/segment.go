@@ -0,0 +1,363 @@
+package joblog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionEnum defines how finished logfiles are compressed.
+type CompressionEnum string
+
+const (
+	// COMPRESSION_NONE keeps finished logfiles as is.
+	COMPRESSION_NONE CompressionEnum = ""
+	// COMPRESSION_GZIP compresses finished logfiles with gzip (`.gz` suffix).
+	COMPRESSION_GZIP CompressionEnum = "gzip"
+	// COMPRESSION_ZSTD compresses finished logfiles with zstd (`.zst` suffix).
+	COMPRESSION_ZSTD CompressionEnum = "zstd"
+)
+
+// ParseCompression parses the name of a compression. `none` and empty string mean COMPRESSION_NONE.
+func ParseCompression(s string) (CompressionEnum, error) {
+	switch c := CompressionEnum(strings.ToLower(strings.TrimSpace(s))); c {
+	case "none", COMPRESSION_NONE:
+		return COMPRESSION_NONE, nil
+	case COMPRESSION_GZIP, COMPRESSION_ZSTD:
+		return c, nil
+	default:
+		return COMPRESSION_NONE, fmt.Errorf("unknown compression: %q", s)
+	}
+}
+
+// extension returns the file suffix of the compression.
+func (c CompressionEnum) extension() string {
+	switch c {
+	case COMPRESSION_GZIP:
+		return ".gz"
+	case COMPRESSION_ZSTD:
+		return ".zst"
+	}
+	return ""
+}
+
+// Segment is a single file of a job log. A log consists of the rotated segments
+// `<path>.<number>` in ascending order, followed by the active segment `<path>`.
+// Each segment may be compressed.
+type Segment struct {
+	// Path is the location of the segment file.
+	Path string
+	// Number is the rotation number of the segment. It is 0 for the active segment.
+	Number int
+	// Compression is the compression of the segment file.
+	Compression CompressionEnum
+	// Size is the size of the segment file in bytes.
+	Size int64
+}
+
+// Segments lists the segments of the log at path in reading order. If a segment is being
+// compressed, only its uncompressed file is listed.
+func Segments(path string) ([]Segment, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	matches = append(matches, path)
+
+	byNumber := map[int]Segment{}
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		segment, ok := parseSegment(path, match)
+		if !ok {
+			continue
+		}
+		segment.Size = info.Size()
+
+		if existing, found := byNumber[segment.Number]; found && existing.Compression == COMPRESSION_NONE {
+			continue
+		}
+		byNumber[segment.Number] = segment
+	}
+
+	segments := make([]Segment, 0, len(byNumber))
+	for _, segment := range byNumber {
+		segments = append(segments, segment)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		// The active segment is always the last one
+		if segments[i].Number == 0 || segments[j].Number == 0 {
+			return segments[j].Number == 0 && segments[i].Number != 0
+		}
+		return segments[i].Number < segments[j].Number
+	})
+
+	return segments, nil
+}
+
+// parseSegment parses the suffix of a segment file of the log at path.
+func parseSegment(path, file string) (Segment, bool) {
+	segment := Segment{Path: file}
+	suffix := strings.TrimPrefix(file, path)
+
+	for _, c := range []CompressionEnum{COMPRESSION_GZIP, COMPRESSION_ZSTD} {
+		if strings.HasSuffix(suffix, c.extension()) {
+			segment.Compression = c
+			suffix = strings.TrimSuffix(suffix, c.extension())
+			break
+		}
+	}
+
+	if suffix == "" {
+		return segment, true
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(suffix, "."))
+	if err != nil || number < 1 || !strings.HasPrefix(suffix, ".") {
+		return segment, false
+	}
+	segment.Number = number
+	return segment, true
+}
+
+// Open returns a reader of the whole log at path, reading every segment in order and
+// decompressing them transparently.
+func Open(path string) (io.ReadCloser, error) {
+	segments, err := Segments(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	return OpenSegments(segments), nil
+}
+
+// OpenActive returns a reader of the active segment of the log at path only.
+// It falls back to the compressed file if the segment was compressed in the meantime.
+func OpenActive(path string) (io.ReadCloser, error) {
+	return openSegment(Segment{Path: path})
+}
+
+// OpenSegments returns a reader of the segments in the given order.
+func OpenSegments(segments []Segment) io.ReadCloser {
+	return &segmentReader{segments: segments}
+}
+
+// segmentReader reads the segments one after another. Each segment is opened lazily.
+type segmentReader struct {
+	segments []Segment
+	current  io.ReadCloser
+}
+
+func (r *segmentReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.segments) == 0 {
+				return 0, io.EOF
+			}
+
+			current, err := openSegment(r.segments[0])
+			r.segments = r.segments[1:]
+			if errors.Is(err, os.ErrNotExist) {
+				// The segment was removed in the meantime, e.g. by the rotation
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			r.current = current
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *segmentReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
+
+// openSegment opens a segment file for reading. If an uncompressed segment was compressed
+// in the meantime, the compressed file is opened instead.
+func openSegment(segment Segment) (io.ReadCloser, error) {
+	f, err := os.Open(segment.Path)
+	if errors.Is(err, os.ErrNotExist) && segment.Compression == COMPRESSION_NONE {
+		for _, c := range []CompressionEnum{COMPRESSION_GZIP, COMPRESSION_ZSTD} {
+			if f, err = os.Open(segment.Path + c.extension()); err == nil {
+				segment.Compression = c
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch segment.Compression {
+	case COMPRESSION_GZIP:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &decompressReader{Reader: gz, closers: []io.Closer{gz, f}}, nil
+	case COMPRESSION_ZSTD:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &decompressReader{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), f}}, nil
+	}
+	return f, nil
+}
+
+// decompressReader closes the decompressor and the underlying file together.
+type decompressReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *decompressReader) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Compress compresses every uncompressed segment of the log at path. Each segment is written
+// into a temporary file first, so readers never see a partial segment.
+func Compress(path string, compression CompressionEnum) error {
+	if compression == COMPRESSION_NONE {
+		return nil
+	}
+
+	segments, err := Segments(path)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if segment.Compression != COMPRESSION_NONE {
+			continue
+		}
+		if err := compressFile(segment.Path, compression); err != nil {
+			return fmt.Errorf("failed to compress %s: %w", segment.Path, err)
+		}
+	}
+	return nil
+}
+
+func compressFile(path string, compression CompressionEnum) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dstPath := path + compression.extension()
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	var w io.WriteCloser
+	if compression == COMPRESSION_ZSTD {
+		if w, err = zstd.NewWriter(dst); err != nil {
+			dst.Close()
+			return err
+		}
+	} else {
+		w = gzip.NewWriter(dst)
+	}
+
+	if _, err := io.Copy(w, src); err != nil {
+		w.Close()
+		dst.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Size returns the total size of every file of the log at path in bytes.
+func Size(path string) (int64, error) {
+	files, err := logFiles(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	return total, nil
+}
+
+// Remove deletes every file of the log at path, including the rotated and compressed segments.
+// It returns the number of bytes freed.
+func Remove(path string) (int64, error) {
+	files, err := logFiles(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var freed int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return freed, err
+		}
+		freed += info.Size()
+	}
+	return freed, nil
+}
+
+// logFiles lists every file belonging to the log at path.
+func logFiles(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	return append(matches, path), nil
+}
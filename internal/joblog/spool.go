@@ -0,0 +1,180 @@
+package joblog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// spoolStreams are the streams of a job process captured by a Spool, in file descriptor order.
+var spoolStreams = []payload.LogStreamEnum{payload.LOG_STREAM_STDOUT, payload.LOG_STREAM_STDERR}
+
+// spoolReadSize is the size of the reads of a spool file.
+const spoolReadSize = 64 * 1024
+
+// Spool captures the output of a job process in one file per stream, `<path>.<stream>.spool`,
+// which the process writes directly. Unlike pipes, the files do not depend on the daemon: if it
+// crashes, the process keeps writing its output and never gets SIGPIPE, and the next daemon
+// resumes framing the output into the logfile where the previous one stopped.
+//
+// The daemon frames the spooled output into the logfile with Drain, and records how far it read
+// in `<path>.spool`. Drained data is released from the spool files, see releaseSpooled.
+type Spool struct {
+	path    string
+	streams []*spoolStream
+}
+
+type spoolStream struct {
+	file   *os.File
+	out    *streamWriter
+	offset int64
+}
+
+// CreateSpool creates the empty spool files of the log at path and returns the files to be used
+// as stdout and stderr of the job process. The caller closes them once the process is started.
+func CreateSpool(path string) (stdout *os.File, stderr *os.File, err error) {
+	os.Remove(spoolOffsetsPath(path))
+
+	files := make([]*os.File, len(spoolStreams))
+	for i, stream := range spoolStreams {
+		files[i], err = os.OpenFile(spoolPath(path, stream), os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
+		if err != nil {
+			for _, f := range files[:i] {
+				f.Close()
+			}
+			return nil, nil, err
+		}
+	}
+	return files[0], files[1], nil
+}
+
+// OpenSpool opens the spool of the log at path to frame its output into w, from the offsets
+// recorded by the latest Drain. It returns an error wrapping os.ErrNotExist if the log has no spool.
+func OpenSpool(path string, w *Writer) (*Spool, error) {
+	offsets, err := readSpoolOffsets(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Spool{path: path}
+	for i, stream := range spoolStreams {
+		f, err := os.OpenFile(spoolPath(path, stream), os.O_RDWR, 0)
+		if err != nil {
+			s.closeFiles()
+			return nil, err
+		}
+		s.streams = append(s.streams, &spoolStream{
+			file:   f,
+			out:    &streamWriter{w: w, stream: stream},
+			offset: offsets[i],
+		})
+	}
+	return s, nil
+}
+
+// Drain frames the output spooled since the previous call into the logfile, and records the
+// offsets. Partial lines stay in the spool until they are complete, so they are not lost if the
+// daemon stops. Write errors of the logfile are logged and the output is dropped, so the spool
+// does not grow forever.
+func (s *Spool) Drain() error {
+	buf := make([]byte, spoolReadSize)
+	for _, st := range s.streams {
+		// offset is the end of the last complete line, so the partial line is read again
+		st.out.buf = nil
+		pos := st.offset
+		for {
+			n, err := st.file.ReadAt(buf, pos)
+			if n > 0 {
+				st.out.Write(buf[:n])
+				pos += int64(n)
+			}
+			if err == io.EOF || n == 0 {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+		st.offset = pos - int64(len(st.out.buf))
+		releaseSpooled(st.file, st.offset)
+	}
+	return s.writeOffsets()
+}
+
+// Close drains the spool, flushes the partial lines and removes the spool files. It must be
+// called once the process exited. Output written afterwards, e.g. by background processes, is lost.
+func (s *Spool) Close() error {
+	err := s.Drain()
+	for _, st := range s.streams {
+		st.out.Close()
+	}
+	s.closeFiles()
+	return errors.Join(err, RemoveSpool(s.path))
+}
+
+func (s *Spool) closeFiles() {
+	for _, st := range s.streams {
+		st.file.Close()
+	}
+}
+
+// writeOffsets records the offset of every stream, see Drain.
+func (s *Spool) writeOffsets() error {
+	offsets := make([]string, len(s.streams))
+	for i, st := range s.streams {
+		offsets[i] = strconv.FormatInt(st.offset, 10)
+	}
+
+	tmp := spoolOffsetsPath(s.path) + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(offsets, " ")+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, spoolOffsetsPath(s.path))
+}
+
+// RemoveSpool deletes the spool files of the log at path, if any.
+func RemoveSpool(path string) error {
+	var errs []error
+	for _, file := range []string{spoolPath(path, payload.LOG_STREAM_STDOUT), spoolPath(path, payload.LOG_STREAM_STDERR), spoolOffsetsPath(path)} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readSpoolOffsets reads the offsets recorded by Drain. Without record, every stream starts at 0.
+func readSpoolOffsets(path string) ([]int64, error) {
+	offsets := make([]int64, len(spoolStreams))
+	content, err := os.ReadFile(spoolOffsetsPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return offsets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) != len(offsets) {
+		return nil, fmt.Errorf("invalid spool offsets in %s", spoolOffsetsPath(path))
+	}
+	for i, field := range fields {
+		if offsets[i], err = strconv.ParseInt(field, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid spool offsets in %s", spoolOffsetsPath(path))
+		}
+	}
+	return offsets, nil
+}
+
+func spoolPath(path string, stream payload.LogStreamEnum) string {
+	return fmt.Sprintf("%s.%s.spool", path, stream)
+}
+
+func spoolOffsetsPath(path string) string {
+	return path + ".spool"
+}
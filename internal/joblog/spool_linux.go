@@ -0,0 +1,17 @@
+package joblog
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// releaseSpooled frees the disk space of the data of the spool file before offset, which was
+// already framed into the logfile. The file keeps its size, so the offsets stay valid.
+func releaseSpooled(f *os.File, offset int64) {
+	// Only whole blocks are freed, the rest is freed by a later call
+	const blockSize = 4096
+	if length := offset &^ (blockSize - 1); length > 0 {
+		unix.Fallocate(int(f.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, 0, length)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package joblog
+
+import "os"
+
+// releaseSpooled does nothing: punching holes is only supported on Linux, so the spool files
+// keep the whole output until the job is finished.
+func releaseSpooled(f *os.File, offset int64) {}
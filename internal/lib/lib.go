@@ -1,12 +1,16 @@
 package lib
 
 import (
+	"bufio"
 	"crypto/md5"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"math"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -54,3 +58,118 @@ func HumanizeDuration(duration time.Duration) string {
 		int64(remainingMinutes), int64(remainingSeconds),
 	)
 }
+
+// ParseDuration parses a duration like time.ParseDuration, and additionally accepts a number
+// of days with the `d` suffix (e.g. `30d`).
+func ParseDuration(duration string) (time.Duration, error) {
+	s := strings.TrimSpace(duration)
+	if days, found := strings.CutSuffix(s, "d"); found {
+		value, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", duration)
+		}
+		return time.Duration(value * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// MatchLike reports whether s matches the pattern like the SQLite LIKE operator: `%` matches
+// any sequence of characters, `_` matches a single character, and the match is case-insensitive.
+func MatchLike(pattern, s string) bool {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	return err == nil && re.MatchString(s)
+}
+
+// ParseEnvFile reads a dotenv-style file and returns its variables.
+//
+// Empty lines and lines starting with `#` are ignored. An optional `export ` prefix is
+// stripped, and values wrapped in matching single or double quotes are unquoted.
+func ParseEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	env := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNumber)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// byteUnits are the binary units supported by ParseByteSize and HumanizeBytes.
+var byteUnits = []string{"B", "K", "M", "G", "T"}
+
+// ParseByteSize parses a size such as `512`, `64K`, `100M` or `2G` (binary units, an optional
+// trailing `B` or `iB` is accepted) into bytes.
+func ParseByteSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+
+	multiplier := int64(1)
+	for i := len(byteUnits) - 1; i > 0; i-- {
+		if strings.HasSuffix(s, byteUnits[i]) {
+			s = strings.TrimSuffix(s, byteUnits[i])
+			multiplier = int64(1) << (10 * i)
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// HumanizeBytes formats a size in bytes with a binary unit, e.g. `1.5M`.
+func HumanizeBytes(size int64) string {
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(byteUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%dB", size)
+	}
+	return fmt.Sprintf("%.1f%s", value, byteUnits[unit])
+}
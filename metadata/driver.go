@@ -0,0 +1,85 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	gosqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/mplus-oss/bobbit.go/internal/metrics"
+)
+
+// instrumentedDriverName is the SQLite driver recording the latency of every statement, see queryDuration.
+const instrumentedDriverName = "sqlite3-instrumented"
+
+// queryDuration is the latency of SQLite statements until they return their result.
+// Reading the rows of a query is not included.
+var queryDuration = metrics.Default.NewHistogramVec(
+	"bobbitd_sqlite_query_duration_seconds",
+	"Latency of SQLite statements by operation (exec or query).",
+	[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	"operation",
+)
+
+func init() {
+	sql.Register(instrumentedDriverName, &instrumentedDriver{&gosqlite3.SQLiteDriver{}})
+	sqlx.BindDriver(instrumentedDriverName, sqlx.QUESTION)
+}
+
+// instrumentedDriver wraps the go-sqlite3 driver, so its connections are instrumented.
+type instrumentedDriver struct {
+	*gosqlite3.SQLiteDriver
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{conn.(*gosqlite3.SQLiteConn)}, nil
+}
+
+// instrumentedConn times the statements of a connection. The other methods are promoted
+// from SQLiteConn, so database/sql still sees every optional interface of the driver.
+type instrumentedConn struct {
+	*gosqlite3.SQLiteConn
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	defer observeQuery("exec", time.Now())
+	return c.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	defer observeQuery("query", time.Now())
+	return c.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.SQLiteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{stmt.(*gosqlite3.SQLiteStmt)}, nil
+}
+
+// instrumentedStmt times the executions of a prepared statement.
+type instrumentedStmt struct {
+	*gosqlite3.SQLiteStmt
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	defer observeQuery("exec", time.Now())
+	return s.SQLiteStmt.ExecContext(ctx, args)
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	defer observeQuery("query", time.Now())
+	return s.SQLiteStmt.QueryContext(ctx, args)
+}
+
+func observeQuery(operation string, start time.Time) {
+	queryDuration.Observe(time.Since(start).Seconds(), operation)
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/mplus-oss/bobbit.go/config"
 	"github.com/mplus-oss/bobbit.go/internal/dblib"
 )
@@ -19,11 +18,16 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// connectionPragmas are applied by the driver to every connection of the pool. Unlike the
+// pragmas of enablePragma, they are not persisted in the database and would be lost when
+// connections are recycled.
+const connectionPragmas = "_foreign_keys=on&_busy_timeout=5000&_synchronous=NORMAL"
+
 func InitDB(cfg config.BobbitDaemonConfig) (*sqlx.DB, error) {
 	log.Println("Connecting to local database.")
 	db, err := sqlx.Open(
-		"sqlite3",
-		path.Join(cfg.DataPath, "metadata.db"),
+		instrumentedDriverName,
+		path.Join(cfg.DataPath, "metadata.db")+"?"+connectionPragmas,
 	)
 	if err != nil {
 		return nil, err
@@ -79,9 +83,6 @@ func runMigration(db *sqlx.DB) error {
 
 	enablePragma(db, map[string]string{
 		"journal_mode": "WAL",
-		"foreign_keys": "ON",
-		"busy_timeout": "5000",
-		"synchronous":  "NORMAL",
 	})
 
 	mustExecQuery(db)
@@ -0,0 +1,3 @@
+ALTER TABLE jobs ADD COLUMN work_dir TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN env TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE jobs ADD COLUMN inherit_env INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN timeout INTEGER NOT NULL DEFAULT 0; -- Nanoseconds
+ALTER TABLE jobs ADD COLUMN kill_grace_period INTEGER NOT NULL DEFAULT 0; -- Nanoseconds
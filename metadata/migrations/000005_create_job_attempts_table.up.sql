@@ -0,0 +1,14 @@
+ALTER TABLE jobs ADD COLUMN retry_policy TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS job_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    pid INTEGER NOT NULL DEFAULT 0,
+    exit_code INTEGER NOT NULL DEFAULT -1,
+    started_at DATETIME NOT NULL DEFAULT current_timestamp,
+    finished_at DATETIME,
+    UNIQUE (job_id, attempt)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_attempts_job_id ON job_attempts(job_id);
@@ -0,0 +1,3 @@
+ALTER TABLE jobs ADD COLUMN queue TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queue ON jobs(queue, status);
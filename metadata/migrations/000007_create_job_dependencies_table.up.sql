@@ -0,0 +1,9 @@
+ALTER TABLE jobs ADD COLUMN dependency_condition TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS job_dependencies (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    depends_on_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    PRIMARY KEY (job_id, depends_on_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_dependencies_depends_on_id ON job_dependencies(depends_on_id);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    expression TEXT NOT NULL,
+    overlap_policy TEXT NOT NULL DEFAULT 'skip',
+    paused INTEGER NOT NULL DEFAULT 0,
+    job_template TEXT NOT NULL DEFAULT '{}', -- JSON representation of JobDetailMetadata
+    last_job_id TEXT NOT NULL DEFAULT '',
+    last_run_at DATETIME,
+    next_run_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT current_timestamp,
+    updated_at DATETIME NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_next_run_at ON schedules(next_run_at);
+
+CREATE TRIGGER IF NOT EXISTS schedules_update_updated_at
+    AFTER UPDATE ON schedules
+    FOR EACH ROW
+    WHEN OLD.updated_at = NEW.updated_at
+BEGIN
+    UPDATE schedules
+    SET updated_at = current_timestamp
+    WHERE id = OLD.id;
+END;
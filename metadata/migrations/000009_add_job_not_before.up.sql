@@ -0,0 +1,3 @@
+ALTER TABLE jobs ADD COLUMN not_before DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status_not_before ON jobs(status, not_before);
@@ -0,0 +1,3 @@
+-- Start time of the job process in clock ticks since boot (/proc/<pid>/stat), used to
+-- verify that the recorded PID still belongs to the job after a daemon restart.
+ALTER TABLE jobs ADD COLUMN pid_start_time INTEGER NOT NULL DEFAULT 0;
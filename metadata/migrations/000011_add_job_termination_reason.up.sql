@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN termination_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN signal INTEGER NOT NULL DEFAULT 0;
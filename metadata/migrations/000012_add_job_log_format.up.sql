@@ -0,0 +1,2 @@
+-- Empty format is the raw output of jobs created before stdout and stderr were captured separately.
+ALTER TABLE jobs ADD COLUMN log_format TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN log_max_size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN log_policy TEXT NOT NULL DEFAULT '';
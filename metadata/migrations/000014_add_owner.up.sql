@@ -0,0 +1,5 @@
+-- NULL for jobs and schedules created before the owner was recorded.
+ALTER TABLE jobs ADD COLUMN uid INTEGER;
+ALTER TABLE jobs ADD COLUMN gid INTEGER;
+ALTER TABLE schedules ADD COLUMN uid INTEGER;
+ALTER TABLE schedules ADD COLUMN gid INTEGER;
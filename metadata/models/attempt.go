@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// JobAttemptModel represents a single row in the 'job_attempts' database table.
+// Every execution of a job command is recorded as an attempt linked to 'jobs.id'.
+type JobAttemptModel struct {
+	ID         int64        `db:"id"`
+	JobID      string       `db:"job_id"`
+	Attempt    int          `db:"attempt"`
+	PID        int          `db:"pid"`
+	ExitCode   int          `db:"exit_code"`
+	StartedAt  time.Time    `db:"started_at"`
+	FinishedAt sql.NullTime `db:"finished_at"`
+	BaseModel
+}
+
+// Save create new attempt in the table and stores the generated row ID.
+func (a *JobAttemptModel) Save() error {
+	query := `
+		INSERT INTO job_attempts (job_id, attempt, pid, exit_code, started_at)
+		VALUES (:job_id, :attempt, :pid, :exit_code, :started_at)
+	`
+	res, err := a.DB.NamedExec(query, a)
+	if err != nil {
+		return fmt.Errorf("failed to save attempt %d of job %s: %w", a.Attempt, a.JobID, err)
+	}
+
+	a.ID, err = res.LastInsertId()
+	return err
+}
+
+// MarkAttemptStarted records the process of the attempt.
+//
+// To use this function, the required property is `JobAttemptModel.ID` and `JobAttemptModel.PID`.
+func (a *JobAttemptModel) MarkAttemptStarted() error {
+	if _, err := a.DB.NamedExec(`UPDATE job_attempts SET pid = :pid WHERE id = :id`, a); err != nil {
+		return fmt.Errorf("failed to mark attempt %d of job %s as started: %w", a.Attempt, a.JobID, err)
+	}
+
+	return nil
+}
+
+// MarkAttemptFinished updates the exit code and the finish time of the attempt.
+//
+// To use this function, the required property is `JobAttemptModel.ID` and `JobAttemptModel.ExitCode`.
+func (a *JobAttemptModel) MarkAttemptFinished() error {
+	a.FinishedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	query := `UPDATE job_attempts SET exit_code = :exit_code, finished_at = :finished_at WHERE id = :id`
+	if _, err := a.DB.NamedExec(query, a); err != nil {
+		return fmt.Errorf("failed to mark attempt %d of job %s as finished: %w", a.Attempt, a.JobID, err)
+	}
+
+	return nil
+}
+
+// GetByJobID fetch every attempt of the job ordered by the attempt number.
+func (a *JobAttemptModel) GetByJobID(jobID string) ([]*JobAttemptModel, error) {
+	query := `
+		SELECT id, job_id, attempt, pid, exit_code, started_at, finished_at
+		FROM job_attempts
+		WHERE job_id = ?
+		ORDER BY attempt ASC
+	`
+
+	var attempts []*JobAttemptModel
+	if err := a.DB.Select(&attempts, query, jobID); err != nil {
+		return nil, err
+	}
+
+	for i := range attempts {
+		attempts[i].BaseModel = a.BaseModel
+	}
+
+	return attempts, nil
+}
+
+// ToPayload converts the raw database model into a JobAttempt struct.
+func (a *JobAttemptModel) ToPayload() payload.JobAttempt {
+	attempt := payload.JobAttempt{
+		Attempt:   a.Attempt,
+		PID:       a.PID,
+		ExitCode:  a.ExitCode,
+		StartedAt: a.StartedAt,
+	}
+	if a.FinishedAt.Valid {
+		finishedAt := a.FinishedAt.Time
+		attempt.FinishedAt = &finishedAt
+	}
+	return attempt
+}
+
+// NewJobAttemptModel creates a database-ready JobAttemptModel for the given attempt of the job.
+func NewJobAttemptModel(db *sqlx.DB, jobID string, attempt int) *JobAttemptModel {
+	return &JobAttemptModel{
+		JobID:     jobID,
+		Attempt:   attempt,
+		ExitCode:  -1,
+		StartedAt: time.Now().UTC(),
+		BaseModel: BaseModel{DB: db},
+	}
+}
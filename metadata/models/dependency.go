@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// SaveDependencies records that the job depends on every job in dependsOnIDs.
+// The edges are stored in the 'job_dependencies' table within a single transaction.
+func (j *JobModel) SaveDependencies(dependsOnIDs []string) error {
+	trx, err := j.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer trx.Rollback()
+
+	for _, id := range dependsOnIDs {
+		if _, err := trx.Exec(
+			"INSERT INTO job_dependencies (job_id, depends_on_id) VALUES (?, ?)",
+			j.ID, id,
+		); err != nil {
+			return fmt.Errorf("failed to save dependency %s of job %s: %w", id, j.ID, err)
+		}
+	}
+
+	return trx.Commit()
+}
+
+// GetDependencyIDs returns the IDs of the jobs that jobID directly depends on.
+func (j *JobModel) GetDependencyIDs(jobID string) ([]string, error) {
+	var ids []string
+	query := "SELECT depends_on_id FROM job_dependencies WHERE job_id = ?"
+	if err := j.DB.Select(&ids, query, jobID); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetUpstream fetch the jobs this job depends on.
+func (j *JobModel) GetUpstream() ([]*JobModel, error) {
+	query := j.buildSelectQuery(&JobFilter{HideCommand: true}) + `
+		WHERE id IN (SELECT depends_on_id FROM job_dependencies WHERE job_id = ?)
+		ORDER BY created_at ASC
+	`
+	return j.selectJobs(query, j.ID)
+}
+
+// GetDownstream fetch the jobs that depend on this job.
+func (j *JobModel) GetDownstream() ([]*JobModel, error) {
+	query := j.buildSelectQuery(&JobFilter{HideCommand: true}) + `
+		WHERE id IN (SELECT job_id FROM job_dependencies WHERE depends_on_id = ?)
+		ORDER BY created_at ASC
+	`
+	return j.selectJobs(query, j.ID)
+}
+
+// GetPendingJobs fetch every job waiting for its dependencies in submission order.
+func (j *JobModel) GetPendingJobs() ([]*JobModel, error) {
+	query := j.buildSelectQuery(&JobFilter{}) + " WHERE status = ? ORDER BY rowid ASC"
+	return j.selectJobs(query, payload.JOB_PENDING)
+}
+
+// ToDependency converts the raw database model into a JobDependency struct.
+func (j *JobModel) ToDependency() payload.JobDependency {
+	return payload.JobDependency{
+		ID:      j.ID,
+		JobName: j.JobName,
+		Status:  payload.JobStatusEnum(j.Status),
+	}
+}
+
+// selectJobs runs the query and attaches the BaseModel to every result.
+func (j *JobModel) selectJobs(query string, args ...any) ([]*JobModel, error) {
+	var jobs []*JobModel
+	if err := j.DB.Select(&jobs, query, args...); err != nil {
+		return nil, err
+	}
+
+	for i := range jobs {
+		jobs[i].BaseModel = j.BaseModel
+	}
+
+	return jobs, nil
+}
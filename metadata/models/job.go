@@ -1,7 +1,6 @@
 package models
 
 import (
-	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -16,15 +15,32 @@ import (
 // JobModel represents a single row in the 'jobs' database table.
 // Complex fields (such as slices or maps) are serialized and stored as JSON strings.
 type JobModel struct {
-	ID        string    `db:"id"`
-	JobName   string    `db:"job_name"`
-	Command   string    `db:"command"` // JSON string representation of []string
-	Status    int       `db:"status"`  // Integer cast from JobStatusEnum
-	ExitCode  int       `db:"exit_code"`
-	Metadata  string    `db:"metadata"` // JSON string representation of PayloadRegularMetadata
-	PID       int       `db:"pid"`
-	CreatedAt time.Time `db:"created_at"` // Generated automatically (current_timestamp)
-	UpdatedAt time.Time `db:"updated_at"` // Generated automatically (TRIGGER jobs_update_updated_at)
+	ID                  string        `db:"id"`
+	JobName             string        `db:"job_name"`
+	Command             string        `db:"command"` // JSON string representation of []string
+	Status              int           `db:"status"`  // Integer cast from JobStatusEnum
+	ExitCode            int           `db:"exit_code"`
+	TerminationReason   string        `db:"termination_reason"`
+	Signal              int           `db:"signal"`
+	Metadata            string        `db:"metadata"` // JSON string representation of PayloadRegularMetadata
+	PID                 int           `db:"pid"`
+	PIDStartTime        uint64        `db:"pid_start_time"` // Clock ticks since boot, see /proc/<pid>/stat
+	WorkDir             string        `db:"work_dir"`
+	Env                 string        `db:"env"` // JSON string representation of map[string]string
+	InheritEnv          bool          `db:"inherit_env"`
+	Timeout             time.Duration `db:"timeout"`
+	KillGracePeriod     time.Duration `db:"kill_grace_period"`
+	RetryPolicy         string        `db:"retry_policy"` // JSON string representation of RetryPolicy
+	Queue               string        `db:"queue"`
+	DependencyCondition string        `db:"dependency_condition"`
+	NotBefore           sql.NullTime  `db:"not_before"` // Stored in UTC
+	LogFormat           string        `db:"log_format"` // See joblog.FORMAT_RAW and joblog.FORMAT_FRAMED
+	LogMaxSize          int64         `db:"log_max_size"`
+	LogPolicy           string        `db:"log_policy"`
+	UID                 sql.NullInt64 `db:"uid"` // Owner of the job, see payload.JobOwner
+	GID                 sql.NullInt64 `db:"gid"`
+	CreatedAt           time.Time     `db:"created_at"` // Stored in UTC, also used for the logfile path
+	UpdatedAt           time.Time     `db:"updated_at"` // Generated automatically (TRIGGER jobs_update_updated_at)
 	BaseModel
 }
 
@@ -33,10 +49,13 @@ type JobFilter struct {
 	// Cannot be combined with FinishOnly.
 	ActiveOnly bool
 
-	// FinishOnly filters results to include only finished jobs whether job is success or failed.
+	// FinishOnly filters results to include only finished jobs, see payload.FinalJobStatuses.
 	// Cannot be combined with ActiveOnly.
 	FinishOnly bool
 
+	// QueuedOnly filters results to include only jobs waiting in the queue.
+	QueuedOnly bool
+
 	// MetadataFilter allows filtering jobs based on their metadata.
 	// Keys are metadata field names, and values are the desired values.
 	MetadataFilter map[string]string
@@ -47,6 +66,9 @@ type JobFilter struct {
 	// HideCommand prevents the command from being exposed in the job response.
 	HideCommand bool
 
+	// OwnerUID filters results to include only jobs submitted by this user.
+	OwnerUID *int
+
 	DBGetFilter
 }
 
@@ -59,8 +81,11 @@ func (j *JobModel) buildSelectQuery(filter *JobFilter) string {
 
 	return fmt.Sprintf(`
 		SELECT
-			id, job_name, %s, status, exit_code,
-			metadata, pid, created_at, updated_at
+			id, job_name, %s, status, exit_code, termination_reason, signal,
+			metadata, pid, pid_start_time, work_dir, env, inherit_env,
+			timeout, kill_grace_period, retry_policy, queue,
+			dependency_condition, not_before, log_format, log_max_size, log_policy,
+			uid, gid, created_at, updated_at
 		FROM jobs
 	`, commandCol)
 }
@@ -92,8 +117,19 @@ func (j *JobModel) applyCriteria(query string, args []any, filter *JobFilter) (s
 
 	// Filter for finished jobs
 	if filter.FinishOnly {
-		whereClauses = append(whereClauses, "(status = ? OR status = ?)")
-		whereArgs = append(whereArgs, payload.JOB_FINISH, payload.JOB_FAILED)
+		whereClauses = append(whereClauses, "status & ? != 0")
+		whereArgs = append(whereArgs, payload.FinalJobStatuses)
+	}
+
+	// Filter for queued jobs
+	if filter.QueuedOnly {
+		whereClauses = append(whereClauses, "status = ?")
+		whereArgs = append(whereArgs, payload.JOB_QUEUED)
+	}
+
+	if filter.OwnerUID != nil {
+		whereClauses = append(whereClauses, "uid = ?")
+		whereArgs = append(whereArgs, *filter.OwnerUID)
 	}
 
 	// Add metadata filtering
@@ -106,8 +142,9 @@ func (j *JobModel) applyCriteria(query string, args []any, filter *JobFilter) (s
 					continue
 				}
 
-				// Using `json_extract(col, $.key)` keyword
-				whereClauses = append(whereClauses, fmt.Sprintf("json_extract(metadata, '$.%s') LIKE ?", k))
+				// Using `json_extract(col, $.key)` keyword. Jobs created without metadata have
+				// an empty string, which is not valid JSON.
+				whereClauses = append(whereClauses, fmt.Sprintf("json_extract(NULLIF(metadata, ''), '$.%s') LIKE ?", k))
 				whereArgs = append(whereArgs, v)
 			}
 		} else {
@@ -147,9 +184,22 @@ func (j *JobModel) applyCriteria(query string, args []any, filter *JobFilter) (s
 // Save create new data in the table
 func (j *JobModel) Save() error {
 	query := `
-		INSERT INTO jobs (id, job_name, command, status, exit_code, metadata)
-		VALUES (:id, :job_name, :command, :status, :exit_code, :metadata)
+		INSERT INTO jobs (
+			id, job_name, command, status, exit_code, metadata,
+			work_dir, env, inherit_env, timeout, kill_grace_period, retry_policy,
+			queue, dependency_condition, not_before, log_format, log_max_size, log_policy,
+			uid, gid, created_at
+		)
+		VALUES (
+			:id, :job_name, :command, :status, :exit_code, :metadata,
+			:work_dir, :env, :inherit_env, :timeout, :kill_grace_period, :retry_policy,
+			:queue, :dependency_condition, :not_before, :log_format, :log_max_size, :log_policy,
+			:uid, :gid, :created_at
+		)
 	`
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = time.Now().UTC()
+	}
 	_, err := j.DB.NamedExec(query, j)
 	return err
 }
@@ -179,55 +229,6 @@ func (j *JobModel) Get(filter *JobFilter) ([]*JobModel, error) {
 	return jobs, nil
 }
 
-// WaitJob will waiting the filter until it finished.
-func (j *JobModel) WaitJob(ctx context.Context, cancel context.CancelFunc, filter *JobFilter) (*JobModel, error) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	var finalJob *JobModel
-	var finalErr error
-	jobFound := false
-
-	for {
-		select {
-		case <-ctx.Done():
-			return finalJob, finalErr
-
-		case <-ticker.C:
-			query := j.buildSelectQuery(filter)
-			args := []any{}
-			filter.Limit = 1
-			query, args = j.applyCriteria(query, args, filter)
-
-			var p JobModel
-			if err := j.DB.GetContext(ctx, &p, query, args...); err != nil {
-				if err == sql.ErrNoRows {
-					// If job was previously found but now not found,
-					// it means the job record was deleted or is no longer matching the filter.
-					if jobFound {
-						finalErr = fmt.Errorf("Job was found but is no longer available")
-						cancel()
-					}
-					continue
-				}
-
-				finalErr = err
-				cancel()
-				continue
-			}
-
-			// Mark that we've found the job at least once
-			jobFound = true
-
-			p.BaseModel = j.BaseModel
-			if p.Status != int(payload.JOB_RUNNING) {
-				finalJob = &p
-				cancel()
-			}
-		}
-	}
-}
-
 // Get fetch the job data from the table and return it as a int of JobModel size.
 func (j *JobModel) Count(filter *JobFilter) (int, error) {
 	query := "SELECT COUNT(*) FROM jobs"
@@ -244,7 +245,7 @@ func (j *JobModel) Count(filter *JobFilter) (int, error) {
 
 // Update persists the current state of the JobModel to the database.
 //
-// NOTE: Ensure that 'Command', 'PID', and 'Metadata' fields (strings) are updated
+// NOTE: Ensure that 'Command', 'PID', 'Metadata', 'Env', and 'RetryPolicy' fields (strings) are updated
 // with the latest JSON content before calling this method.
 func (j *JobModel) Update() error {
 	query := `
@@ -254,8 +255,23 @@ func (j *JobModel) Update() error {
 			command = :command,
 			status = :status,
 			exit_code = :exit_code,
+			termination_reason = :termination_reason,
+			signal = :signal,
 			pid = :pid,
-			metadata = :metadata
+			pid_start_time = :pid_start_time,
+			metadata = :metadata,
+			work_dir = :work_dir,
+			env = :env,
+			inherit_env = :inherit_env,
+			timeout = :timeout,
+			kill_grace_period = :kill_grace_period,
+			retry_policy = :retry_policy,
+			queue = :queue,
+			dependency_condition = :dependency_condition,
+			not_before = :not_before,
+			log_format = :log_format,
+			log_max_size = :log_max_size,
+			log_policy = :log_policy
 		WHERE id = :id
 	`
 
@@ -267,17 +283,48 @@ func (j *JobModel) Update() error {
 	return nil
 }
 
-// MarkFinished updates only the status and exit code of a job.
+// MarkJobStarted updates the status, process and log format of a job whose attempt was started.
+// Other fields, e.g. the metadata, are left untouched as they may be changed while the job runs.
+// It returns false if the job was stopped in the meantime, so its status is kept.
+func (j *JobModel) MarkJobStarted() (bool, error) {
+	res, err := j.DB.Exec(`
+		UPDATE jobs
+		SET status = ?, pid = ?, pid_start_time = ?, log_format = ?
+		WHERE id = ? AND status IN (?, ?)
+	`, j.Status, j.PID, j.PIDStartTime, j.LogFormat, j.ID, payload.JOB_NOT_RUNNING, payload.JOB_RUNNING)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark job %s as started: %w", j.ID, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateMetadata persists JobModel.Metadata, which must be a JSON string.
+func (j *JobModel) UpdateMetadata() error {
+	if _, err := j.DB.NamedExec("UPDATE jobs SET metadata = :metadata WHERE id = :id", j); err != nil {
+		return fmt.Errorf("failed to update metadata of job %s: %w", j.ID, err)
+	}
+
+	return nil
+}
+
+// MarkJobFinished updates the final status, exit code, termination reason and signal of a job.
+// The status is derived from the termination reason and the exit code.
 //
-// To use this function, the required property is `JobModel.ID` and `JobModel.ExitCode`.
+// To use this function, the required property is `JobModel.ID`, `JobModel.ExitCode`
+// and `JobModel.TerminationReason`.
 func (j *JobModel) MarkJobFinished() error {
-	if j.ExitCode == 0 {
-		j.Status = int(payload.JOB_FINISH)
-	} else {
-		j.Status = int(payload.JOB_FAILED)
-	}
+	j.Status = int(payload.TerminationReasonEnum(j.TerminationReason).JobStatus(j.ExitCode))
 
-	query := `UPDATE jobs SET status = :status, exit_code = :exit_code WHERE id = :id`
+	query := `
+		UPDATE jobs
+		SET status = :status, exit_code = :exit_code, termination_reason = :termination_reason, signal = :signal
+		WHERE id = :id
+	`
 	if _, err := j.DB.NamedExec(query, j); err != nil {
 		return fmt.Errorf("failed to mark job %s as finished: %w", j.ID, err)
 	}
@@ -285,6 +332,44 @@ func (j *JobModel) MarkJobFinished() error {
 	return nil
 }
 
+// MarkJobStopped marks a job that is not finished yet as JOB_STOPPED with the reason.
+// It returns false if the job was already finished, so its final status is kept.
+func (j *JobModel) MarkJobStopped(reason payload.TerminationReasonEnum) (bool, error) {
+	finished := []payload.JobStatusEnum{
+		payload.JOB_FINISH, payload.JOB_FAILED, payload.JOB_STOPPED,
+		payload.JOB_TIMED_OUT, payload.JOB_SKIPPED, payload.JOB_LOST,
+	}
+	query, args, err := sqlx.In(
+		"UPDATE jobs SET status = ?, termination_reason = ? WHERE id = ? AND status NOT IN (?)",
+		payload.JOB_STOPPED, reason, j.ID, finished,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := j.DB.Exec(query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark job %s as stopped: %w", j.ID, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	j.Status = int(payload.JOB_STOPPED)
+	j.TerminationReason = string(reason)
+	return true, nil
+}
+
+// GetAttempts fetch every attempt of the job ordered by the attempt number.
+func (j *JobModel) GetAttempts() ([]*JobAttemptModel, error) {
+	attempt := &JobAttemptModel{BaseModel: j.BaseModel}
+	return attempt.GetByJobID(j.ID)
+}
+
 // ToPayload converts the raw database model back into a JobResponse struct.
 // It handles the deserialization of JSON fields to ensure the data is ready for the CLI.
 func (j *JobModel) ToPayload() (*payload.JobResponse, error) {
@@ -300,20 +385,71 @@ func (j *JobModel) ToPayload() (*payload.JobResponse, error) {
 		}
 	}
 
+	var env map[string]string
+	if j.Env != "" {
+		if err := json.Unmarshal([]byte(j.Env), &env); err != nil {
+			return nil, err
+		}
+	}
+
+	var retry *payload.RetryPolicy
+	if j.RetryPolicy != "" {
+		if err := json.Unmarshal([]byte(j.RetryPolicy), &retry); err != nil {
+			return nil, err
+		}
+	}
+
+	var notBefore *time.Time
+	if j.NotBefore.Valid {
+		t := j.NotBefore.Time
+		notBefore = &t
+	}
+
+	inheritEnv := j.InheritEnv
 	return &payload.JobResponse{
-		Status:   payload.JobStatusEnum(j.Status),
-		ExitCode: j.ExitCode,
+		Status:            payload.JobStatusEnum(j.Status),
+		ExitCode:          j.ExitCode,
+		TerminationReason: payload.TerminationReasonEnum(j.TerminationReason),
+		Signal:            j.Signal,
+		Owner:             j.Owner(),
 		JobDetailMetadata: payload.JobDetailMetadata{
-			ID:        j.ID,
-			JobName:   j.JobName,
-			Command:   cmd,
-			Metadata:  meta,
-			CreatedAt: j.CreatedAt,
-			UpdatedAt: j.UpdatedAt,
+			ID:                  j.ID,
+			JobName:             j.JobName,
+			Command:             cmd,
+			Metadata:            meta,
+			WorkDir:             j.WorkDir,
+			Env:                 env,
+			InheritEnv:          &inheritEnv,
+			Timeout:             j.Timeout,
+			KillGracePeriod:     j.KillGracePeriod,
+			Retry:               retry,
+			Queue:               j.Queue,
+			DependencyCondition: payload.DependencyConditionEnum(j.DependencyCondition),
+			NotBefore:           notBefore,
+			LogMaxSize:          j.LogMaxSize,
+			LogPolicy:           payload.LogPolicyEnum(j.LogPolicy),
+			CreatedAt:           j.CreatedAt,
+			UpdatedAt:           j.UpdatedAt,
 		},
 	}, nil
 }
 
+// Owner returns the user who submitted the job, or nil if it is unknown.
+func (j *JobModel) Owner() *payload.JobOwner {
+	if !j.UID.Valid {
+		return nil
+	}
+	return &payload.JobOwner{UID: int(j.UID.Int64), GID: int(j.GID.Int64)}
+}
+
+// ownerColumns converts the owner into the nullable uid and gid columns.
+func ownerColumns(owner *payload.JobOwner) (uid sql.NullInt64, gid sql.NullInt64) {
+	if owner == nil {
+		return uid, gid
+	}
+	return sql.NullInt64{Int64: int64(owner.UID), Valid: true}, sql.NullInt64{Int64: int64(owner.GID), Valid: true}
+}
+
 // BulkToPayload converts the bulk of raw database model back into a bulk of JobResponse struct.
 func (j *JobModel) BulkToPayload(jm []*JobModel) (p []*payload.JobResponse, err error) {
 	if len(jm) == 0 {
@@ -354,6 +490,31 @@ func NewJobModel(db *sqlx.DB, job payload.JobResponse) (*JobModel, error) {
 		metaString = string(metaBytes)
 	}
 
+	envString := "{}"
+	if len(job.Env) > 0 {
+		envBytes, err := json.Marshal(job.Env)
+		if err != nil {
+			return nil, err
+		}
+		envString = string(envBytes)
+	}
+
+	retryString := ""
+	if job.Retry != nil {
+		retryBytes, err := json.Marshal(job.Retry)
+		if err != nil {
+			return nil, err
+		}
+		retryString = string(retryBytes)
+	}
+
+	var notBefore sql.NullTime
+	if job.NotBefore != nil {
+		notBefore = sql.NullTime{Time: job.NotBefore.UTC(), Valid: true}
+	}
+
+	uid, gid := ownerColumns(job.Owner)
+
 	supportsJSON, err := dblib.CheckSQLiteJSONFunctions(db)
 	if err != nil {
 		log.Printf("[WARNING] Failed to check SQLite JSON function support: %v", err)
@@ -361,12 +522,28 @@ func NewJobModel(db *sqlx.DB, job payload.JobResponse) (*JobModel, error) {
 	}
 
 	return &JobModel{
-		ID:        job.ID,
-		JobName:   job.JobName,
-		Command:   string(cmdBytes),
-		Status:    int(job.Status),
-		ExitCode:  job.ExitCode,
-		Metadata:  metaString,
-		BaseModel: BaseModel{DB: db, SupportsJSONFunctions: supportsJSON},
+		ID:                  job.ID,
+		JobName:             job.JobName,
+		Command:             string(cmdBytes),
+		Status:              int(job.Status),
+		ExitCode:            job.ExitCode,
+		TerminationReason:   string(job.TerminationReason),
+		Signal:              job.Signal,
+		Metadata:            metaString,
+		WorkDir:             job.WorkDir,
+		Env:                 envString,
+		InheritEnv:          job.ShouldInheritEnv(),
+		Timeout:             job.Timeout,
+		KillGracePeriod:     job.KillGracePeriod,
+		RetryPolicy:         retryString,
+		Queue:               job.Queue,
+		DependencyCondition: string(job.DependencyCondition),
+		NotBefore:           notBefore,
+		LogMaxSize:          job.LogMaxSize,
+		LogPolicy:           string(job.LogPolicy),
+		UID:                 uid,
+		GID:                 gid,
+		CreatedAt:           job.CreatedAt.UTC(),
+		BaseModel:           BaseModel{DB: db, SupportsJSONFunctions: supportsJSON},
 	}, nil
 }
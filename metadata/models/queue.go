@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// GetQueuedJobs fetch every queued job in submission (FIFO) order.
+func (j *JobModel) GetQueuedJobs() ([]*JobModel, error) {
+	query := j.buildSelectQuery(&JobFilter{}) + " WHERE status = ? ORDER BY rowid ASC"
+	return j.selectJobs(query, payload.JOB_QUEUED)
+}
+
+// GetScheduledJobs fetch every job waiting for its NotBefore time, earliest first.
+func (j *JobModel) GetScheduledJobs() ([]*JobModel, error) {
+	query := j.buildSelectQuery(&JobFilter{}) + " WHERE status = ? ORDER BY not_before ASC, rowid ASC"
+	return j.selectJobs(query, payload.JOB_SCHEDULED)
+}
+
+// GetRunningJobs fetch every job marked as JOB_RUNNING.
+func (j *JobModel) GetRunningJobs() ([]*JobModel, error) {
+	query := j.buildSelectQuery(&JobFilter{}) + " WHERE status = ? ORDER BY rowid ASC"
+	return j.selectJobs(query, payload.JOB_RUNNING)
+}
+
+// ClaimQueuedJob atomically moves the job from JOB_QUEUED to JOB_NOT_RUNNING.
+// It returns false if the job is no longer queued (e.g. it was stopped in the meantime).
+func (j *JobModel) ClaimQueuedJob() (bool, error) {
+	return j.TransitionStatus(payload.JOB_QUEUED, payload.JOB_NOT_RUNNING)
+}
+
+// TransitionStatus atomically updates the job status only if the current status is `from`.
+// It returns false if the job status was changed in the meantime.
+func (j *JobModel) TransitionStatus(from, to payload.JobStatusEnum) (bool, error) {
+	res, err := j.DB.Exec(
+		"UPDATE jobs SET status = ? WHERE id = ? AND status = ?",
+		to, j.ID, from,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update status of job %s: %w", j.ID, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	j.Status = int(to)
+	return true, nil
+}
+
+// RequeueClaimedJobs moves jobs that were claimed but never started (JOB_NOT_RUNNING)
+// back to the queue. It is used when the daemon starts after an unclean shutdown, once
+// MarkStartingJobsRunning moved the jobs whose process may have started.
+func (j *JobModel) RequeueClaimedJobs() (int64, error) {
+	res, err := j.DB.Exec(
+		"UPDATE jobs SET status = ? WHERE status = ?",
+		payload.JOB_QUEUED, payload.JOB_NOT_RUNNING,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue jobs: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// MarkStartingJobsRunning moves the claimed jobs (JOB_NOT_RUNNING) whose attempt was recorded
+// to JOB_RUNNING with the process of the attempt, if known. Their process may have started
+// before the daemon crashed, so they must be reconciled instead of requeued.
+func (j *JobModel) MarkStartingJobsRunning() (int64, error) {
+	res, err := j.DB.Exec(`
+		UPDATE jobs
+		SET status = ?, pid_start_time = 0, pid = (
+			SELECT pid FROM job_attempts
+			WHERE job_id = jobs.id AND finished_at IS NULL
+			ORDER BY attempt DESC LIMIT 1
+		)
+		WHERE status = ? AND EXISTS (
+			SELECT 1 FROM job_attempts WHERE job_id = jobs.id AND finished_at IS NULL
+		)
+	`, payload.JOB_RUNNING, payload.JOB_NOT_RUNNING)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark starting jobs as running: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// QueuePositions returns the 1-based position of every queued job within its queue.
+// Key is the job ID.
+func (j *JobModel) QueuePositions() (map[string]int, error) {
+	var rows []struct {
+		ID    string `db:"id"`
+		Queue string `db:"queue"`
+	}
+	query := "SELECT id, queue FROM jobs WHERE status = ? ORDER BY rowid ASC"
+	if err := j.DB.Select(&rows, query, payload.JOB_QUEUED); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(rows))
+	queueSizes := map[string]int{}
+	for _, row := range rows {
+		queueSizes[row.Queue]++
+		positions[row.ID] = queueSizes[row.Queue]
+	}
+
+	return positions, nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// GetPruneCandidates fetch every finished job that no unfinished job depends on,
+// grouped by job name with the newest job first.
+func (j *JobModel) GetPruneCandidates() ([]*JobModel, error) {
+	query := j.buildSelectQuery(&JobFilter{HideCommand: true}) + `
+		WHERE status & ? != 0
+		AND id NOT IN (
+			SELECT d.depends_on_id FROM job_dependencies d
+			JOIN jobs downstream ON downstream.id = d.job_id
+			WHERE downstream.status & ? = 0
+		)
+		ORDER BY job_name ASC, created_at DESC, rowid DESC
+	`
+	return j.selectJobs(query, payload.FinalJobStatuses, payload.FinalJobStatuses)
+}
+
+// DeleteJobs deletes the jobs with the given IDs and returns the number of deleted rows.
+// Their attempts and dependency edges are deleted in the same transaction, so they do not
+// depend on the foreign keys being enforced.
+func (j *JobModel) DeleteJobs(ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	trx, err := j.DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer trx.Rollback()
+
+	exec := func(query string, args ...any) (sql.Result, error) {
+		query, args, err := sqlx.In(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		return trx.Exec(query, args...)
+	}
+
+	if _, err := exec("DELETE FROM job_attempts WHERE job_id IN (?)", ids); err != nil {
+		return 0, err
+	}
+	if _, err := exec("DELETE FROM job_dependencies WHERE job_id IN (?) OR depends_on_id IN (?)", ids, ids); err != nil {
+		return 0, err
+	}
+	result, err := exec("DELETE FROM jobs WHERE id IN (?)", ids)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return deleted, trx.Commit()
+}
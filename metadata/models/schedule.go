@@ -0,0 +1,207 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mplus-oss/bobbit.go/payload"
+)
+
+// ScheduleModel represents a single row in the 'schedules' database table.
+// The job template is serialized and stored as a JSON string.
+type ScheduleModel struct {
+	ID            string        `db:"id"`
+	Name          string        `db:"name"`
+	Expression    string        `db:"expression"`
+	OverlapPolicy string        `db:"overlap_policy"`
+	Paused        bool          `db:"paused"`
+	JobTemplate   string        `db:"job_template"` // JSON string representation of JobDetailMetadata
+	LastJobID     string        `db:"last_job_id"`
+	LastRunAt     sql.NullTime  `db:"last_run_at"`
+	NextRunAt     sql.NullTime  `db:"next_run_at"`
+	UID           sql.NullInt64 `db:"uid"` // Owner of the schedule, see payload.JobOwner
+	GID           sql.NullInt64 `db:"gid"`
+	CreatedAt     time.Time     `db:"created_at"` // Generated automatically (current_timestamp)
+	UpdatedAt     time.Time     `db:"updated_at"` // Generated automatically (TRIGGER schedules_update_updated_at)
+	BaseModel
+}
+
+const scheduleSelectQuery = `
+	SELECT
+		id, name, expression, overlap_policy, paused, job_template,
+		last_job_id, last_run_at, next_run_at, uid, gid, created_at, updated_at
+	FROM schedules
+`
+
+// Save create new data in the table
+func (s *ScheduleModel) Save() error {
+	query := `
+		INSERT INTO schedules (
+			id, name, expression, overlap_policy, paused, job_template, next_run_at, uid, gid
+		)
+		VALUES (
+			:id, :name, :expression, :overlap_policy, :paused, :job_template, :next_run_at, :uid, :gid
+		)
+	`
+	_, err := s.DB.NamedExec(query, s)
+	return err
+}
+
+// MarkRun persists the latest run of the schedule, `ScheduleModel.LastJobID` and `ScheduleModel.LastRunAt`.
+func (s *ScheduleModel) MarkRun() error {
+	query := "UPDATE schedules SET last_job_id = :last_job_id, last_run_at = :last_run_at WHERE id = :id"
+	if _, err := s.DB.NamedExec(query, s); err != nil {
+		return fmt.Errorf("failed to update last run of schedule %s: %w", s.ID, err)
+	}
+
+	return nil
+}
+
+// SetNextRun persists `ScheduleModel.NextRunAt`, unless the schedule was paused in the meantime.
+func (s *ScheduleModel) SetNextRun() error {
+	query := "UPDATE schedules SET next_run_at = :next_run_at WHERE id = :id AND paused = 0"
+	if _, err := s.DB.NamedExec(query, s); err != nil {
+		return fmt.Errorf("failed to update next run of schedule %s: %w", s.ID, err)
+	}
+
+	return nil
+}
+
+// SetPaused persists `ScheduleModel.Paused` and `ScheduleModel.NextRunAt`.
+func (s *ScheduleModel) SetPaused() error {
+	query := "UPDATE schedules SET paused = :paused, next_run_at = :next_run_at WHERE id = :id"
+	if _, err := s.DB.NamedExec(query, s); err != nil {
+		return fmt.Errorf("failed to update schedule %s: %w", s.ID, err)
+	}
+
+	return nil
+}
+
+// Delete removes the schedule from the table. Jobs spawned by the schedule are kept.
+func (s *ScheduleModel) Delete() error {
+	if _, err := s.DB.NamedExec("DELETE FROM schedules WHERE id = :id", s); err != nil {
+		return fmt.Errorf("failed to delete schedule %s: %w", s.ID, err)
+	}
+	return nil
+}
+
+// Find fetch a schedule by its ID (or prefix) or name. It returns nil if nothing matches.
+func (s *ScheduleModel) Find(search string) (*ScheduleModel, error) {
+	query := scheduleSelectQuery + " WHERE id LIKE ? OR name = ? ORDER BY created_at DESC LIMIT 1"
+
+	var schedules []*ScheduleModel
+	if err := s.DB.Select(&schedules, query, search+"%", search); err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, nil
+	}
+
+	schedules[0].BaseModel = s.BaseModel
+	return schedules[0], nil
+}
+
+// Get fetch every schedule. If activeOnly is true, paused schedules are excluded.
+func (s *ScheduleModel) Get(activeOnly bool) ([]*ScheduleModel, error) {
+	query := scheduleSelectQuery
+	if activeOnly {
+		query += " WHERE paused = 0"
+	}
+	query += " ORDER BY name ASC"
+
+	var schedules []*ScheduleModel
+	if err := s.DB.Select(&schedules, query); err != nil {
+		return nil, err
+	}
+
+	for i := range schedules {
+		schedules[i].BaseModel = s.BaseModel
+	}
+
+	return schedules, nil
+}
+
+// ToPayload converts the raw database model back into a ScheduleResponse struct.
+func (s *ScheduleModel) ToPayload() (*payload.ScheduleResponse, error) {
+	var template payload.JobDetailMetadata
+	if err := json.Unmarshal([]byte(s.JobTemplate), &template); err != nil {
+		return nil, err
+	}
+
+	resp := &payload.ScheduleResponse{
+		LastJobID: s.LastJobID,
+		Owner:     s.Owner(),
+		ScheduleDetail: payload.ScheduleDetail{
+			ID:            s.ID,
+			Name:          s.Name,
+			Expression:    s.Expression,
+			OverlapPolicy: payload.OverlapPolicyEnum(s.OverlapPolicy),
+			Paused:        s.Paused,
+			Job:           template,
+			CreatedAt:     s.CreatedAt,
+			UpdatedAt:     s.UpdatedAt,
+		},
+	}
+	if s.LastRunAt.Valid {
+		lastRunAt := s.LastRunAt.Time
+		resp.LastRunAt = &lastRunAt
+	}
+	if s.NextRunAt.Valid {
+		nextRunAt := s.NextRunAt.Time
+		resp.NextRunAt = &nextRunAt
+	}
+
+	return resp, nil
+}
+
+// BulkToPayload converts the bulk of raw database model back into a bulk of ScheduleResponse struct.
+func (s *ScheduleModel) BulkToPayload(sm []*ScheduleModel) ([]*payload.ScheduleResponse, error) {
+	p := make([]*payload.ScheduleResponse, 0, len(sm))
+	for _, v := range sm {
+		pv, err := v.ToPayload()
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, pv)
+	}
+	return p, nil
+}
+
+// Owner returns the user who created the schedule, or nil if it is unknown.
+func (s *ScheduleModel) Owner() *payload.JobOwner {
+	if !s.UID.Valid {
+		return nil
+	}
+	return &payload.JobOwner{UID: int(s.UID.Int64), GID: int(s.GID.Int64)}
+}
+
+// SetOwner sets the user who created the schedule.
+func (s *ScheduleModel) SetOwner(owner *payload.JobOwner) {
+	s.UID, s.GID = ownerColumns(owner)
+}
+
+// NewScheduleModel creates a database-ready ScheduleModel from a ScheduleDetail object.
+// It serializes the job template into a JSON string to prepare for database insertion.
+func NewScheduleModel(db *sqlx.DB, schedule payload.ScheduleDetail) (*ScheduleModel, error) {
+	templateBytes, err := json.Marshal(schedule.Job)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.OverlapPolicy == "" {
+		schedule.OverlapPolicy = payload.OVERLAP_SKIP
+	}
+
+	return &ScheduleModel{
+		ID:            schedule.ID,
+		Name:          schedule.Name,
+		Expression:    schedule.Expression,
+		OverlapPolicy: string(schedule.OverlapPolicy),
+		Paused:        schedule.Paused,
+		JobTemplate:   string(templateBytes),
+		BaseModel:     BaseModel{DB: db},
+	}, nil
+}
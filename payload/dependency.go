@@ -0,0 +1,26 @@
+package payload
+
+// DependencyConditionEnum defines which outcome of the upstream jobs allows a job to start.
+type DependencyConditionEnum string
+
+const (
+	// DEPENDENCY_ON_SUCCESS starts the job when every upstream job finished successfully.
+	DEPENDENCY_ON_SUCCESS DependencyConditionEnum = "success"
+	// DEPENDENCY_ON_COMPLETION starts the job when every upstream job was executed, whatever the outcome.
+	DEPENDENCY_ON_COMPLETION DependencyConditionEnum = "completion"
+	// DEPENDENCY_ON_FAILURE starts the job when every upstream job failed or timed out.
+	DEPENDENCY_ON_FAILURE DependencyConditionEnum = "failure"
+)
+
+// IsSatisfiedBy reports whether a finished upstream job with the given status satisfies the condition.
+// Skipped upstream jobs never satisfy any condition, so skips cascade to downstream jobs.
+func (c DependencyConditionEnum) IsSatisfiedBy(status JobStatusEnum) bool {
+	switch c {
+	case DEPENDENCY_ON_COMPLETION:
+		return status.IsFinished() && status != JOB_SKIPPED
+	case DEPENDENCY_ON_FAILURE:
+		return status == JOB_FAILED || status == JOB_TIMED_OUT
+	default:
+		return status == JOB_FINISH
+	}
+}
@@ -27,4 +27,63 @@ type JobDetailMetadata struct {
 	// MetadataFilter allows filtering jobs based on their metadata.
 	// It's a map where keys are metadata field names and values are the desired values.
 	MetadataFilter map[string]string `json:"metadata_filter,omitempty"`
+
+	// WorkDir specifies the working directory of the job process.
+	// If empty, the job runs in the working directory of the daemon.
+	WorkDir string `json:"work_dir,omitempty"`
+
+	// Env contains additional environment variables passed to the job process.
+	// Keys are variable names, and values are the variable values.
+	Env map[string]string `json:"env,omitempty"`
+
+	// InheritEnv controls whether the job process inherits the environment of the daemon.
+	// If not provided, the environment is inherited.
+	InheritEnv *bool `json:"inherit_env,omitempty"`
+
+	// Timeout bounds how long the job process may run. When the deadline passes, the process
+	// group receives SIGTERM and the job is marked as JOB_TIMED_OUT. Zero means no timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// KillGracePeriod is the time between SIGTERM and SIGKILL once the timeout passes.
+	// If not provided, the daemon default is used.
+	KillGracePeriod time.Duration `json:"kill_grace_period,omitempty"`
+
+	// Queue is the name of the queue the job is submitted to. Jobs in the same queue share
+	// the concurrency limit of the queue. If empty, the default queue is used.
+	Queue string `json:"queue,omitempty"`
+
+	// DependsOn lists the jobs (ID or name) that must finish before this job is started.
+	// Names resolve to the latest job with that name. The daemon replaces the references
+	// with the resolved job IDs.
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// DependencyCondition defines which outcome of the DependsOn jobs allows this job to start.
+	// If the condition can no longer be met, the job is marked as JOB_SKIPPED.
+	// Defaults to DEPENDENCY_ON_SUCCESS.
+	DependencyCondition DependencyConditionEnum `json:"dependency_condition,omitempty"`
+
+	// NotBefore is the earliest time the job may start. Until then, the job is kept as
+	// JOB_SCHEDULED. If not provided, the job may start immediately.
+	NotBefore *time.Time `json:"not_before,omitempty"`
+
+	// Delay postpones the start of the job relative to the submission time.
+	// It is ignored if NotBefore is provided.
+	Delay time.Duration `json:"delay,omitempty"`
+
+	// LogMaxSize is the maximum size of the job log in bytes. If not provided, the daemon
+	// default is used.
+	LogMaxSize int64 `json:"log_max_size,omitempty"`
+
+	// LogPolicy defines what happens when the job log reaches LogMaxSize.
+	// If not provided, the daemon default is used.
+	LogPolicy LogPolicyEnum `json:"log_policy,omitempty"`
+
+	// Retry defines how the daemon re-executes the command when it fails.
+	// If not provided, the command is executed only once.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+}
+
+// ShouldInheritEnv reports whether the job process should inherit the environment of the daemon.
+func (j JobDetailMetadata) ShouldInheritEnv() bool {
+	return j.InheritEnv == nil || *j.InheritEnv
 }
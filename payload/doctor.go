@@ -0,0 +1,47 @@
+package payload
+
+import "time"
+
+// ReconcileActionEnum defines what the daemon did with a job left running by a previous daemon.
+type ReconcileActionEnum string
+
+const (
+	// RECONCILE_ADOPTED indicates that the job process is still alive and monitored again.
+	RECONCILE_ADOPTED ReconcileActionEnum = "adopted"
+	// RECONCILE_LOST indicates that the job process is gone, so the job is marked as JOB_LOST.
+	RECONCILE_LOST ReconcileActionEnum = "lost"
+)
+
+// ReconciledJob represents a job that was found running when the daemon started.
+type ReconciledJob struct {
+	// ID is the unique identifier of the job.
+	ID string `json:"id"`
+
+	// JobName is the name of the job.
+	JobName string `json:"job_name"`
+
+	// PID is the recorded process ID of the job.
+	PID int `json:"pid"`
+
+	// Action is what the daemon did with the job.
+	Action ReconcileActionEnum `json:"action"`
+
+	// Reason explains the action.
+	Reason string `json:"reason"`
+}
+
+// DoctorResponse represents the health report of the daemon.
+type DoctorResponse struct {
+	// StartedAt indicates when the daemon was started.
+	StartedAt time.Time `json:"started_at"`
+
+	// Reconciled lists the jobs the daemon found running on startup.
+	Reconciled []ReconciledJob `json:"reconciled"`
+
+	// RunningJobs is the number of jobs currently executed or monitored by the daemon.
+	RunningJobs int `json:"running_jobs"`
+
+	// Untracked lists the jobs marked as running in the database that are not
+	// executed or monitored by the daemon. It should always be empty.
+	Untracked []JobDependency `json:"untracked"`
+}
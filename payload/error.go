@@ -1,7 +1,37 @@
 package payload
 
+// ErrorCodeEnum is a stable, machine-readable kind of an error response.
+type ErrorCodeEnum string
+
+const (
+	// ERROR_INTERNAL is a failure of the daemon itself, e.g. of the database.
+	ERROR_INTERNAL ErrorCodeEnum = "internal"
+	// ERROR_INVALID_REQUEST is a request with invalid or missing metadata.
+	ERROR_INVALID_REQUEST ErrorCodeEnum = "invalid_request"
+	// ERROR_UNKNOWN_REQUEST is a request type the daemon does not handle.
+	ERROR_UNKNOWN_REQUEST ErrorCodeEnum = "unknown_request"
+	// ERROR_JOB_NOT_FOUND is returned when no job matches the search.
+	ERROR_JOB_NOT_FOUND ErrorCodeEnum = "job_not_found"
+	// ERROR_SCHEDULE_NOT_FOUND is returned when no schedule matches the search.
+	ERROR_SCHEDULE_NOT_FOUND ErrorCodeEnum = "schedule_not_found"
+	// ERROR_LOG_NOT_FOUND is returned when the logfile of a job does not exist.
+	ERROR_LOG_NOT_FOUND ErrorCodeEnum = "log_not_found"
+	// ERROR_FORBIDDEN is returned when the authorization policy does not allow the request.
+	ERROR_FORBIDDEN ErrorCodeEnum = "forbidden"
+	// ERROR_CONFLICT is returned when the current state of a job or schedule prevents the request.
+	ERROR_CONFLICT ErrorCodeEnum = "conflict"
+	// ERROR_SUBSCRIBER_DROPPED ends an event stream the client did not read fast enough.
+	ERROR_SUBSCRIBER_DROPPED ErrorCodeEnum = "subscriber_dropped"
+)
+
 // JobError is used to unmarshal error messages from a JSON response payload.
-// It expects a JSON object with a single "error" key containing the error message.
+// It expects a JSON object with an "error" key containing the error message, and its
+// machine-readable "code".
 type JobErrorResponse struct {
-	Error string `json:"error"`
+	Error string        `json:"error"`
+	Code  ErrorCodeEnum `json:"code,omitempty"`
+	// JobID is the job the error relates to, if any.
+	JobID string `json:"job_id,omitempty"`
+	// Details is the underlying cause of the error.
+	Details string `json:"details,omitempty"`
 }
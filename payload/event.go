@@ -0,0 +1,95 @@
+package payload
+
+import "time"
+
+// JobEventTypeEnum defines the kind of change in the lifecycle of a job.
+type JobEventTypeEnum string
+
+const (
+	// EVENT_CREATED is published when a job is submitted.
+	EVENT_CREATED JobEventTypeEnum = "created"
+	// EVENT_STATUS_CHANGED is published when a job moves between the waiting statuses,
+	// e.g. from JOB_SCHEDULED or JOB_PENDING to JOB_QUEUED.
+	EVENT_STATUS_CHANGED JobEventTypeEnum = "status-changed"
+	// EVENT_STARTED is published when an attempt of the job is started.
+	EVENT_STARTED JobEventTypeEnum = "started"
+	// EVENT_FINISHED is published when a job reaches a final status other than JOB_STOPPED.
+	EVENT_FINISHED JobEventTypeEnum = "finished"
+	// EVENT_STOPPED is published when a job is stopped.
+	EVENT_STOPPED JobEventTypeEnum = "stopped"
+	// EVENT_METADATA_UPDATED is published when the metadata of a job is changed.
+	EVENT_METADATA_UPDATED JobEventTypeEnum = "metadata-updated"
+)
+
+// JobEvent represents a state transition of a job.
+type JobEvent struct {
+	// Type is the kind of the transition.
+	Type JobEventTypeEnum `json:"type"`
+
+	// ID is the unique identifier of the job.
+	ID string `json:"id"`
+
+	// JobName is the name given to the job.
+	JobName string `json:"job_name"`
+
+	// Status is the status of the job after the transition.
+	Status JobStatusEnum `json:"status"`
+
+	// ExitCode is the exit code of the job. It is only meaningful once the job is finished.
+	ExitCode int `json:"exitcode"`
+
+	// TerminationReason explains why the job ended. It is empty while the job is not finished.
+	TerminationReason TerminationReasonEnum `json:"termination_reason,omitempty"`
+
+	// Signal is the number of the signal that killed the job process, or 0 if it exited by itself.
+	Signal int `json:"signal,omitempty"`
+
+	// Metadata contains the metadata of the job.
+	Metadata PayloadRegularMetadata `json:"metadata,omitempty"`
+
+	// Owner is the user who submitted the job, see JobResponse.Owner.
+	Owner *JobOwner `json:"owner,omitempty"`
+
+	// Timestamp indicates when the transition happened.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WatchRequestMetadata is the metadata of a REQUEST_WATCH payload request.
+// Every filter must match for an event to be sent. Empty filters match every event.
+type WatchRequestMetadata struct {
+	// IDPrefix filters the events by the prefix of the job ID.
+	IDPrefix string `json:"id_prefix,omitempty"`
+
+	// JobName filters the events by the job name. SQL LIKE wildcards (`%` and `_`) are supported.
+	JobName string `json:"job_name,omitempty"`
+
+	// MetadataFilter filters the events by the job metadata, like JobSearchMetadata.MetadataFilter.
+	MetadataFilter map[string]string `json:"metadata_filter,omitempty"`
+
+	// Types filters the events by their type.
+	Types []JobEventTypeEnum `json:"types,omitempty"`
+
+	// History is the maximum number of recent events replayed before the live events.
+	// The daemon only keeps a limited number of events in memory.
+	History int `json:"history,omitempty"`
+
+	// Follow keeps the connection open and streams the new events.
+	// If false, only the replayed events are sent.
+	Follow bool `json:"follow,omitempty"`
+}
+
+// WatchResponse is the first response of a REQUEST_WATCH payload request. It is sent once
+// the subscription is registered, and followed by newline-delimited JobEvent.
+type WatchResponse struct {
+	// History is the number of replayed events that follow this response.
+	History int `json:"history"`
+}
+
+// JobMetadataUpdateRequest is the metadata of a REQUEST_UPDATE_METADATA payload request.
+type JobMetadataUpdateRequest struct {
+	// Search is the ID or name of the job. The latest matching job is updated.
+	Search string `json:"search"`
+
+	// Metadata is merged into the metadata of the job. Keys with a null value are removed.
+	Metadata map[string]any `json:"metadata"`
+}
@@ -0,0 +1,61 @@
+package payload
+
+import (
+	"slices"
+	"time"
+)
+
+// ProtocolVersion is the version of the wire protocol implemented by this build. It is
+// increased whenever a request or response changes in a way older builds misunderstand.
+const ProtocolVersion = 2
+
+// MinProtocolVersion is the oldest protocol version of the other side this build still
+// understands. Daemons without REQUEST_INFO speak protocol version 1: they do not list their
+// requests, so clients send every request and let them reject the unknown ones.
+const MinProtocolVersion = 1
+
+// FeatureEnum defines an optional capability of the daemon.
+type FeatureEnum string
+
+const (
+	// FEATURE_JSON_FUNCTIONS indicates that SQLite supports JSON functions, so metadata
+	// filters are matched per key instead of on the raw metadata.
+	FEATURE_JSON_FUNCTIONS FeatureEnum = "json_functions"
+	// FEATURE_QUEUEING indicates that the number of running jobs is limited, globally or per queue.
+	FEATURE_QUEUEING FeatureEnum = "queueing"
+	// FEATURE_SCHEDULING indicates that recurring jobs are supported.
+	FEATURE_SCHEDULING FeatureEnum = "scheduling"
+	// FEATURE_RETENTION indicates that old finished jobs are deleted by the retention janitor.
+	FEATURE_RETENTION FeatureEnum = "retention"
+	// FEATURE_LOG_COMPRESSION indicates that logs of finished jobs are compressed.
+	FEATURE_LOG_COMPRESSION FeatureEnum = "log_compression"
+	// FEATURE_ERROR_CODES indicates that error responses have a machine-readable code.
+	FEATURE_ERROR_CODES FeatureEnum = "error_codes"
+)
+
+// InfoResponse describes the daemon, so clients can check they are compatible with it.
+type InfoResponse struct {
+	// Version is the version of the daemon build.
+	Version string `json:"version"`
+
+	// ProtocolVersion is the wire protocol version of the daemon.
+	ProtocolVersion int `json:"protocol_version"`
+
+	// MinProtocolVersion is the oldest client protocol version the daemon understands.
+	MinProtocolVersion int `json:"min_protocol_version"`
+
+	// Requests lists the request types handled by the daemon.
+	Requests []PayloadRequestEnum `json:"requests"`
+
+	// Features reports the optional capabilities of the daemon and whether they are enabled.
+	Features map[FeatureEnum]bool `json:"features"`
+
+	// StartedAt indicates when the daemon was started.
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Supports reports whether the daemon handles the request type. Daemons of protocol version 1
+// do not list their requests, so they are assumed to handle every request.
+func (i InfoResponse) Supports(request PayloadRequestEnum) bool {
+	return i.ProtocolVersion < 2 || slices.Contains(i.Requests, request)
+}
@@ -0,0 +1,42 @@
+package payload
+
+import "time"
+
+// LogStreamEnum defines the origin of a job log line.
+type LogStreamEnum string
+
+const (
+	// LOG_STREAM_STDOUT indicates that the line was written to the standard output of the job.
+	LOG_STREAM_STDOUT LogStreamEnum = "stdout"
+	// LOG_STREAM_STDERR indicates that the line was written to the standard error of the job.
+	LOG_STREAM_STDERR LogStreamEnum = "stderr"
+	// LOG_STREAM_DAEMON indicates that the line was written by the daemon, e.g. retry attempt markers.
+	LOG_STREAM_DAEMON LogStreamEnum = "daemon"
+)
+
+// LogPolicyEnum defines what happens when a job log reaches its maximum size.
+type LogPolicyEnum string
+
+const (
+	// LOG_POLICY_ROTATE moves the log into a numbered segment and continues in a new file.
+	// Only the latest segments are kept.
+	LOG_POLICY_ROTATE LogPolicyEnum = "rotate"
+	// LOG_POLICY_TRUNCATE drops the oldest half of the log.
+	LOG_POLICY_TRUNCATE LogPolicyEnum = "truncate"
+	// LOG_POLICY_STOP stops capturing the output of the job.
+	LOG_POLICY_STOP LogPolicyEnum = "stop"
+)
+
+// LogLine represents a single line of a job log, as streamed by REQUEST_TAIL_LOG.
+type LogLine struct {
+	// Line is the text of the line without the trailing newline.
+	Line string `json:"line"`
+
+	// Stream is the origin of the line. It is empty for logs written before the streams
+	// were captured separately.
+	Stream LogStreamEnum `json:"stream,omitempty"`
+
+	// Timestamp indicates when the line was written. It is nil for logs written before
+	// the streams were captured separately.
+	Timestamp *time.Time `json:"ts,omitempty"`
+}
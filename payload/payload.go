@@ -25,6 +25,24 @@ const (
 	// REQUEST_TAIL_LOG indicates a request to tail/stream a job's log file in real-time.
 	// Returns streaming log lines until connection is closed or job completes.
 	REQUEST_TAIL_LOG
+	// REQUEST_SCHEDULE indicates a request to manage recurring jobs.
+	// The operation and its return are defined by ScheduleRequestMetadata.Action.
+	REQUEST_SCHEDULE
+	// REQUEST_DOCTOR indicates a request to inspect the health of the daemon.
+	// Returns DoctorResponse.
+	REQUEST_DOCTOR
+	// REQUEST_PRUNE indicates a request to delete old finished jobs and their logfiles.
+	// Returns PruneResponse.
+	REQUEST_PRUNE
+	// REQUEST_WATCH indicates a request to stream the lifecycle events of jobs.
+	// Returns WatchResponse, followed by JobEvent until the connection is closed.
+	REQUEST_WATCH
+	// REQUEST_UPDATE_METADATA indicates a request to merge new metadata into a job.
+	// Returns JobResponse.
+	REQUEST_UPDATE_METADATA
+	// REQUEST_INFO indicates a request to describe the daemon, e.g. its protocol version.
+	// Returns InfoResponse.
+	REQUEST_INFO
 )
 
 // ParsePayloadRequest return humanize value of PayloadRequestEnum
@@ -44,6 +62,18 @@ func ParsePayloadRequest(payloadReq PayloadRequestEnum) (status string) {
 		status = "STOP"
 	case REQUEST_TAIL_LOG:
 		status = "TAIL_LOG"
+	case REQUEST_SCHEDULE:
+		status = "SCHEDULE"
+	case REQUEST_DOCTOR:
+		status = "DOCTOR"
+	case REQUEST_PRUNE:
+		status = "PRUNE"
+	case REQUEST_WATCH:
+		status = "WATCH"
+	case REQUEST_UPDATE_METADATA:
+		status = "UPDATE_METADATA"
+	case REQUEST_INFO:
+		status = "INFO"
 	default:
 		status = "UNKNOWN"
 	}
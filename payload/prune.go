@@ -0,0 +1,59 @@
+package payload
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PruneRequestMetadata is the metadata of a REQUEST_PRUNE payload request.
+// A job is pruned if it matches OlderThan or MaxPerName. Only finished jobs are pruned,
+// and jobs with unfinished downstream jobs are always kept.
+type PruneRequestMetadata struct {
+	// OlderThan prunes jobs that were finished longer than this duration ago.
+	// Zero means any age.
+	OlderThan time.Duration `json:"older_than,omitempty"`
+
+	// Statuses limits pruning to jobs with these statuses. Every status must be a final status.
+	// If empty, every final status is pruned.
+	Statuses []JobStatusEnum `json:"statuses,omitempty"`
+
+	// MaxPerName prunes the oldest jobs of every job name beyond this number, regardless of OlderThan.
+	// Zero means unlimited.
+	MaxPerName int `json:"max_per_name,omitempty"`
+
+	// DryRun reports what would be pruned without deleting anything.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// PruneResponse represents the result of a REQUEST_PRUNE payload request.
+type PruneResponse struct {
+	// Jobs lists the pruned jobs.
+	Jobs []JobDependency `json:"jobs"`
+
+	// ReclaimedBytes is the size of the deleted log files.
+	ReclaimedBytes int64 `json:"reclaimed_bytes"`
+
+	// DryRun indicates that nothing was deleted.
+	DryRun bool `json:"dry_run"`
+}
+
+// ParseJobStatusName parses the name of a final job status, e.g. `failed` or `timed-out`.
+// The name `finished` means JOB_FINISH.
+func ParseJobStatusName(name string) (JobStatusEnum, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "-") {
+	case "finished", "finish", "success":
+		return JOB_FINISH, nil
+	case "failed":
+		return JOB_FAILED, nil
+	case "stopped":
+		return JOB_STOPPED, nil
+	case "timed-out", "timeout":
+		return JOB_TIMED_OUT, nil
+	case "skipped":
+		return JOB_SKIPPED, nil
+	case "lost":
+		return JOB_LOST, nil
+	}
+	return 0, fmt.Errorf("unknown final status: %q", name)
+}
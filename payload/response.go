@@ -1,5 +1,7 @@
 package payload
 
+import "time"
+
 // JobStatusEnum represents the status of a job.
 type JobStatusEnum int32
 
@@ -14,8 +16,29 @@ const (
 	JOB_NOT_RUNNING
 	// JOB_STOPPED indicates that the job is stopped.
 	JOB_STOPPED
+	// JOB_TIMED_OUT indicates that the job was terminated after exceeding its timeout.
+	JOB_TIMED_OUT
+	// JOB_QUEUED indicates that the job is waiting for a free execution slot.
+	JOB_QUEUED
+	// JOB_PENDING indicates that the job is waiting for its dependencies to finish.
+	JOB_PENDING
+	// JOB_SKIPPED indicates that the job was never executed because its dependency condition was not met.
+	JOB_SKIPPED
+	// JOB_SCHEDULED indicates that the job is waiting for its NotBefore time.
+	JOB_SCHEDULED
+	// JOB_LOST indicates that the daemon lost track of the job process, e.g. after a crash.
+	// The outcome of the job is unknown.
+	JOB_LOST
 )
 
+// FinalJobStatuses is the mask of every final status.
+const FinalJobStatuses = JOB_FINISH | JOB_FAILED | JOB_STOPPED | JOB_TIMED_OUT | JOB_SKIPPED | JOB_LOST
+
+// IsFinished reports whether the job has reached a final status and will not be executed anymore.
+func (s JobStatusEnum) IsFinished() bool {
+	return s&FinalJobStatuses > 0
+}
+
 // JobResponse represents the detailed response for a job query.
 //
 // It includes the job's status, exit code, and additional job details.
@@ -23,13 +46,79 @@ type JobResponse struct {
 	// Status indicates the current status of the job.
 	Status JobStatusEnum `json:"status"`
 
-	// ExitCode provides the exit code of the job process.
+	// ExitCode provides the exit code of the job process. If the process was killed
+	// by a signal, it is 128 plus the signal number.
 	ExitCode int `json:"exitcode"`
 
+	// TerminationReason explains why the job ended. It is empty while the job is not finished.
+	TerminationReason TerminationReasonEnum `json:"termination_reason,omitempty"`
+
+	// Signal is the number of the signal that killed the job process, or 0 if it exited by itself.
+	Signal int `json:"signal,omitempty"`
+
+	// QueuePosition is the 1-based position of the job in its queue. It is 0 if the job is not queued.
+	QueuePosition int `json:"queue_position,omitempty"`
+
+	// Upstream lists the jobs this job depends on. It is only populated
+	// when requesting the status of a specific job.
+	Upstream []JobDependency `json:"upstream,omitempty"`
+
+	// Downstream lists the jobs that depend on this job. It is only populated
+	// when requesting the status of a specific job.
+	Downstream []JobDependency `json:"downstream,omitempty"`
+
+	// Attempts lists every execution of the job command. It is only populated
+	// when requesting the status of a specific job.
+	Attempts []JobAttempt `json:"attempts,omitempty"`
+
+	// Owner is the user who submitted the job. It is nil for jobs submitted before
+	// the daemon recorded owners.
+	Owner *JobOwner `json:"owner,omitempty"`
+
 	// JobDetailMetadata embeds additional metadata about the job.
 	JobDetailMetadata
 }
 
+// JobOwner identifies the user who submitted a job or schedule, taken from the
+// credentials of the client connection.
+type JobOwner struct {
+	// UID is the user ID of the client process.
+	UID int `json:"uid"`
+
+	// GID is the group ID of the client process.
+	GID int `json:"gid"`
+}
+
+// JobDependency represents a job on the other side of a dependency edge.
+type JobDependency struct {
+	// ID is the unique identifier of the job.
+	ID string `json:"id"`
+
+	// JobName is the name of the job.
+	JobName string `json:"job_name"`
+
+	// Status indicates the current status of the job.
+	Status JobStatusEnum `json:"status"`
+}
+
+// JobAttempt represents a single execution of a job command.
+type JobAttempt struct {
+	// Attempt is the attempt number, starting from 1.
+	Attempt int `json:"attempt"`
+
+	// PID is the process ID of the attempt.
+	PID int `json:"pid"`
+
+	// ExitCode provides the exit code of the attempt. It is -1 while the attempt is running.
+	ExitCode int `json:"exitcode"`
+
+	// StartedAt indicates when the attempt was started.
+	StartedAt time.Time `json:"started_at"`
+
+	// FinishedAt indicates when the attempt was finished. It is nil while the attempt is running.
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
 // JobResponseCount represents a response containing only the count of jobs.
 type JobResponseCount struct {
 
@@ -49,6 +138,18 @@ func ParseJobStatus(jobStatus JobStatusEnum) (status string) {
 		status = "Running"
 	case JOB_STOPPED:
 		status = "Stopped"
+	case JOB_TIMED_OUT:
+		status = "Timed out"
+	case JOB_QUEUED:
+		status = "Queued"
+	case JOB_PENDING:
+		status = "Pending"
+	case JOB_SKIPPED:
+		status = "Skipped"
+	case JOB_SCHEDULED:
+		status = "Scheduled"
+	case JOB_LOST:
+		status = "Lost"
 	default:
 		status = "Unknown"
 	}
@@ -0,0 +1,78 @@
+package payload
+
+import (
+	"slices"
+	"time"
+)
+
+// RetryBackoffEnum defines how the delay between retry attempts grows.
+type RetryBackoffEnum string
+
+const (
+	// RETRY_BACKOFF_FIXED waits the same delay before every retry attempt.
+	RETRY_BACKOFF_FIXED RetryBackoffEnum = "fixed"
+	// RETRY_BACKOFF_EXPONENTIAL doubles the delay after every retry attempt.
+	RETRY_BACKOFF_EXPONENTIAL RetryBackoffEnum = "exponential"
+)
+
+// RetryPolicy defines how the daemon re-executes a job whose command failed.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int `json:"max_attempts"`
+
+	// Backoff defines how the delay grows between attempts. Defaults to RETRY_BACKOFF_FIXED.
+	Backoff RetryBackoffEnum `json:"backoff,omitempty"`
+
+	// Delay is the time to wait before the first retry. With RETRY_BACKOFF_EXPONENTIAL,
+	// it is the base delay that is doubled after every attempt.
+	Delay time.Duration `json:"delay,omitempty"`
+
+	// MaxDelay caps the delay between attempts. Zero means no cap.
+	MaxDelay time.Duration `json:"max_delay,omitempty"`
+
+	// RetryOnExitCodes restricts retries to the listed exit codes.
+	// If empty, every non-zero exit code is retried.
+	RetryOnExitCodes []int `json:"retry_on_exit_codes,omitempty"`
+
+	// RetryOnTimeout retries the attempts terminated by the job timeout, see TERMINATION_TIMEOUT.
+	// By default they are not retried, as a hanging command would likely time out again.
+	RetryOnTimeout bool `json:"retry_on_timeout,omitempty"`
+}
+
+// ShouldRetry reports whether an attempt that exited with exitCode for the reason should be
+// retried. It does not take the number of attempts into account.
+func (r *RetryPolicy) ShouldRetry(exitCode int, reason TerminationReasonEnum) bool {
+	if exitCode == 0 {
+		return false
+	}
+	if reason == TERMINATION_TIMEOUT {
+		return r.RetryOnTimeout
+	}
+	if len(r.RetryOnExitCodes) == 0 {
+		return true
+	}
+	return slices.Contains(r.RetryOnExitCodes, exitCode)
+}
+
+// BackoffDelay returns the time to wait after the given attempt number (starting from 1)
+// before the next attempt is executed.
+func (r *RetryPolicy) BackoffDelay(attempt int) time.Duration {
+	delay := r.Delay
+	if r.Backoff == RETRY_BACKOFF_EXPONENTIAL {
+		for i := 1; i < attempt; i++ {
+			if r.MaxDelay > 0 && delay >= r.MaxDelay {
+				break
+			}
+			// Stop doubling before the duration overflows
+			if delay > time.Duration(1<<62) {
+				break
+			}
+			delay *= 2
+		}
+	}
+
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,94 @@
+package payload
+
+import "time"
+
+// ScheduleActionEnum defines the operation of a REQUEST_SCHEDULE payload request.
+type ScheduleActionEnum int32
+
+const (
+	// SCHEDULE_ADD creates a new schedule. Return of this action is ScheduleResponse.
+	SCHEDULE_ADD ScheduleActionEnum = 1 << iota
+	// SCHEDULE_LIST lists every schedule. Return of this action is []ScheduleResponse.
+	SCHEDULE_LIST
+	// SCHEDULE_REMOVE deletes a schedule. Return of this action is ScheduleResponse.
+	SCHEDULE_REMOVE
+	// SCHEDULE_PAUSE stops a schedule from spawning jobs. Return of this action is ScheduleResponse.
+	SCHEDULE_PAUSE
+	// SCHEDULE_RESUME lets a paused schedule spawn jobs again. Return of this action is ScheduleResponse.
+	SCHEDULE_RESUME
+	// SCHEDULE_TRIGGER spawns a job from the schedule immediately. Return of this action is JobResponse.
+	SCHEDULE_TRIGGER
+)
+
+// OverlapPolicyEnum defines what happens when a schedule fires while its previous job is still active.
+type OverlapPolicyEnum string
+
+const (
+	// OVERLAP_SKIP does not spawn a new job while the previous one is still active.
+	OVERLAP_SKIP OverlapPolicyEnum = "skip"
+	// OVERLAP_QUEUE spawns a new job that starts once the previous one is finished.
+	OVERLAP_QUEUE OverlapPolicyEnum = "queue"
+	// OVERLAP_ALLOW spawns a new job regardless of the previous one.
+	OVERLAP_ALLOW OverlapPolicyEnum = "allow"
+)
+
+// ScheduleRequestMetadata is the metadata of a REQUEST_SCHEDULE payload request.
+type ScheduleRequestMetadata struct {
+	// Action specifies the operation to be performed on the schedules.
+	Action ScheduleActionEnum `json:"action"`
+
+	// Search specifies the schedule ID (or prefix) or name. Required for every action
+	// except SCHEDULE_ADD and SCHEDULE_LIST.
+	Search string `json:"search,omitempty"`
+
+	// Schedule is the definition of the new schedule. Required for SCHEDULE_ADD.
+	Schedule *ScheduleDetail `json:"schedule,omitempty"`
+}
+
+// ScheduleDetail provides detailed information about a recurring job.
+type ScheduleDetail struct {
+	// ID is the unique identifier for the schedule.
+	ID string `json:"id"`
+
+	// Name is the unique name given to the schedule.
+	Name string `json:"name"`
+
+	// Expression is a standard 5-field cron expression, a descriptor such as `@daily`,
+	// or an interval such as `@every 5m`.
+	Expression string `json:"expression"`
+
+	// OverlapPolicy defines what happens when the previous job is still active.
+	// Defaults to OVERLAP_SKIP.
+	OverlapPolicy OverlapPolicyEnum `json:"overlap_policy,omitempty"`
+
+	// Paused indicates that the schedule does not spawn jobs.
+	Paused bool `json:"paused"`
+
+	// Job is the template of the spawned jobs. ID, CreatedAt and DependsOn are ignored.
+	// If JobName is empty, the schedule name is used.
+	Job JobDetailMetadata `json:"job"`
+
+	// CreatedAt indicates when the schedule was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt indicates when the schedule was changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ScheduleResponse represents the detailed response for a schedule query.
+type ScheduleResponse struct {
+	// NextRunAt indicates when the schedule fires next. It is nil if the schedule is paused.
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+
+	// LastRunAt indicates when the schedule fired last time.
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+
+	// LastJobID is the ID of the latest job spawned by the schedule.
+	LastJobID string `json:"last_job_id,omitempty"`
+
+	// Owner is the user who created the schedule. Jobs spawned by the schedule are owned by this user.
+	Owner *JobOwner `json:"owner,omitempty"`
+
+	// ScheduleDetail embeds the definition of the schedule.
+	ScheduleDetail
+}
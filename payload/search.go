@@ -16,10 +16,13 @@ type JobSearchMetadata struct {
 	// Cannot be combined with FinishOnly.
 	ActiveOnly bool `json:"active_only,omitempty"`
 
-	// FinishOnly filters results to include only finished jobs whether job is success or failed.
+	// FinishOnly filters results to include only finished jobs, see FinalJobStatuses.
 	// Cannot be combined with ActiveOnly.
 	FinishOnly bool `json:"finish_only,omitempty"`
 
+	// QueuedOnly filters results to include only jobs waiting in the queue.
+	QueuedOnly bool `json:"queued_only,omitempty"`
+
 	// Page specifies the page number for pagination. When set,
 	// the Limit field will be used to determine the maximum number of results per page.
 	Page int `json:"page,omitempty"`
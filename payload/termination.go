@@ -0,0 +1,34 @@
+package payload
+
+// TerminationReasonEnum explains why the job process ended.
+type TerminationReasonEnum string
+
+const (
+	// TERMINATION_EXITED indicates that the process exited by itself.
+	TERMINATION_EXITED TerminationReasonEnum = "exited"
+	// TERMINATION_SIGNALED indicates that the process was killed by a signal not sent by the daemon.
+	TERMINATION_SIGNALED TerminationReasonEnum = "signaled"
+	// TERMINATION_STOPPED_BY_USER indicates that the job was stopped with a REQUEST_STOP request.
+	TERMINATION_STOPPED_BY_USER TerminationReasonEnum = "stopped-by-user"
+	// TERMINATION_TIMEOUT indicates that the process was terminated after exceeding the job timeout.
+	TERMINATION_TIMEOUT TerminationReasonEnum = "timeout"
+	// TERMINATION_START_FAILED indicates that the command could not be started.
+	TERMINATION_START_FAILED TerminationReasonEnum = "start-failed"
+	// TERMINATION_DAEMON_SHUTDOWN indicates that the job was stopped because the daemon was shutting down.
+	TERMINATION_DAEMON_SHUTDOWN TerminationReasonEnum = "daemon-shutdown"
+)
+
+// JobStatus returns the final status of a job that ended for this reason with the exit code.
+func (r TerminationReasonEnum) JobStatus(exitCode int) JobStatusEnum {
+	switch r {
+	case TERMINATION_STOPPED_BY_USER, TERMINATION_DAEMON_SHUTDOWN:
+		return JOB_STOPPED
+	case TERMINATION_TIMEOUT:
+		return JOB_TIMED_OUT
+	case TERMINATION_EXITED:
+		if exitCode == 0 {
+			return JOB_FINISH
+		}
+	}
+	return JOB_FAILED
+}
@@ -0,0 +1,47 @@
+package payload
+
+import "time"
+
+// WaitModeEnum defines when a wait for multiple jobs is done.
+type WaitModeEnum string
+
+const (
+	// WAIT_ALL waits until every waited job is finished.
+	WAIT_ALL WaitModeEnum = "all"
+	// WAIT_ANY waits until one of the waited jobs is finished.
+	WAIT_ANY WaitModeEnum = "any"
+)
+
+// WaitRequestMetadata is the metadata of a REQUEST_WAIT payload request.
+//
+// The waited jobs are the latest job of every target, and every job matching the metadata filter.
+// Targets that do not match any job yet are waited for until such a job is created.
+type WaitRequestMetadata struct {
+	// Search is a single target, the same as JobSearchMetadata.Search. If it is the only
+	// target, the daemon returns a JobResponse instead of a WaitResponse for older clients,
+	// except when Deadline has passed.
+	Search string `json:"search,omitempty"`
+
+	// Targets lists the ID or name of the waited jobs.
+	Targets []string `json:"targets,omitempty"`
+
+	// MetadataFilter waits for every job with this metadata, like JobSearchMetadata.MetadataFilter.
+	MetadataFilter map[string]string `json:"metadata_filter,omitempty"`
+
+	// Mode defines whether to wait for any or all jobs. The default is WAIT_ALL.
+	Mode WaitModeEnum `json:"mode,omitempty"`
+
+	// Deadline stops waiting at this time. The daemon responds with WaitResponse.TimedOut.
+	// If nil, the wait never times out.
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// WaitResponse represents the result of a REQUEST_WAIT payload request.
+type WaitResponse struct {
+	// Jobs lists the waited jobs with their current status. Jobs that are not finished
+	// are included as well, e.g. when waiting for any job or when timed out.
+	Jobs []JobResponse `json:"jobs"`
+
+	// TimedOut indicates that the deadline passed before the wait was done.
+	TimedOut bool `json:"timed_out"`
+}